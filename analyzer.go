@@ -0,0 +1,92 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// Analyzer analyzes the latest samples of a player or the final output of a context, e.g. for music visualizers.
+//
+// An Analyzer keeps the latest samples as mono, which are copied without blocking the audio.
+// The analysis runs on the caller's goroutine.
+//
+// All the methods of Analyzer are concurrent-safe.
+type Analyzer struct {
+	analyzer *mux.Analyzer
+}
+
+// NewAnalyzer creates a new Analyzer with the FFT size.
+// size must be a power of two and at least 32, e.g. 2048.
+//
+// The created Analyzer does nothing until it is attached by Context.SetAnalyzer or Player.SetAnalyzer.
+func (c *Context) NewAnalyzer(size int) (*Analyzer, error) {
+	a, err := mux.NewAnalyzer(size, c.sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return &Analyzer{analyzer: a}, nil
+}
+
+// Size returns the FFT size.
+func (a *Analyzer) Size() int {
+	return a.analyzer.Size()
+}
+
+// Waveform appends the latest samples from the oldest to dst, and returns the result.
+// The number of the samples is the FFT size.
+func (a *Analyzer) Waveform(dst []float32) []float32 {
+	return a.analyzer.Waveform(dst)
+}
+
+// Spectrum appends the magnitudes of the frequency bins of the Hann-windowed latest samples to dst, and returns the result.
+//
+// The number of the bins is size/2+1, and the frequency of the k-th bin is k * sampleRate / size.
+// A sine wave of amplitude 1 has a magnitude of about 1.
+func (a *Analyzer) Spectrum(dst []float32) []float32 {
+	return a.analyzer.Spectrum(dst)
+}
+
+// LogSpectrum appends the magnitudes of the logarithmically spaced bands from minFreq to maxFreq in Hz to dst, and returns the result.
+// The magnitude of a band is the root mean square of the bins in the band.
+// minFreq must be positive.
+func (a *Analyzer) LogSpectrum(dst []float32, bands int, minFreq, maxFreq float64) []float32 {
+	return a.analyzer.LogSpectrum(dst, bands, minFreq, maxFreq)
+}
+
+// SetAnalyzer attaches the analyzer to the final output of the context.
+// If a is nil, the current analyzer is detached.
+//
+// SetAnalyzer is concurrent-safe.
+func (c *Context) SetAnalyzer(a *Analyzer) {
+	if a == nil {
+		c.mux.SetAnalyzer(nil)
+		return
+	}
+	c.mux.SetAnalyzer(a.analyzer)
+}
+
+// SetAnalyzer attaches the analyzer to the player.
+// The analyzer receives the player's samples after its volume is applied.
+// If a is nil, the current analyzer is detached.
+//
+// An analyzer should be attached to only one player or context at the same time.
+func (p *Player) SetAnalyzer(a *Analyzer) {
+	if a == nil {
+		p.player.SetAnalyzer(nil)
+		return
+	}
+	p.player.SetAnalyzer(a.analyzer)
+}
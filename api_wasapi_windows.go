@@ -315,6 +315,18 @@ func (i *_IAudioClient2) Initialize(shareMode _AUDCLNT_SHAREMODE, streamFlags ui
 	return nil
 }
 
+func (i *_IAudioClient2) GetStreamLatency() (_REFERENCE_TIME, error) {
+	var latency _REFERENCE_TIME
+	r, _, _ := syscall.Syscall(i.vtbl.GetStreamLatency, 2, uintptr(unsafe.Pointer(i)), uintptr(unsafe.Pointer(&latency)), 0)
+	if uint32(r) != uint32(windows.S_OK) {
+		if isAudclntErr(uint32(r)) {
+			return 0, fmt.Errorf("oto: IAudioClient2::GetStreamLatency failed: %w", _AUDCLNT_ERR(r))
+		}
+		return 0, fmt.Errorf("oto: IAudioClient2::GetStreamLatency failed: HRESULT(%d)", uint32(r))
+	}
+	return latency, nil
+}
+
 func (i *_IAudioClient2) IsFormatSupported(shareMode _AUDCLNT_SHAREMODE, pFormat *_WAVEFORMATEXTENSIBLE) (*_WAVEFORMATEXTENSIBLE, error) {
 	var closestMatch *_WAVEFORMATEXTENSIBLE
 	r, _, _ := syscall.Syscall6(i.vtbl.IsFormatSupported, 4, uintptr(unsafe.Pointer(i)),
@@ -30,6 +30,7 @@ var (
 	procWaveOutOpen            = winmm.NewProc("waveOutOpen")
 	procWaveOutClose           = winmm.NewProc("waveOutClose")
 	procWaveOutPrepareHeader   = winmm.NewProc("waveOutPrepareHeader")
+	procWaveOutReset           = winmm.NewProc("waveOutReset")
 	procWaveOutUnprepareHeader = winmm.NewProc("waveOutUnprepareHeader")
 	procWaveOutWrite           = winmm.NewProc("waveOutWrite")
 )
@@ -149,6 +150,17 @@ func waveOutPrepareHeader(hwo uintptr, pwh *_WAVEHDR) error {
 	return nil
 }
 
+func waveOutReset(hwo uintptr) error {
+	r, _, e := procWaveOutReset.Call(hwo)
+	if _MMRESULT(r) != _MMSYSERR_NOERROR {
+		if e != nil && e != windows.ERROR_SUCCESS {
+			return fmt.Errorf("oto: waveOutReset failed: %w", e)
+		}
+		return fmt.Errorf("oto: waveOutReset failed: %w", _MMRESULT(r))
+	}
+	return nil
+}
+
 func waveOutUnprepareHeader(hwo uintptr, pwh *_WAVEHDR) error {
 	r, _, e := procWaveOutUnprepareHeader.Call(hwo, uintptr(unsafe.Pointer(pwh)), unsafe.Sizeof(_WAVEHDR{}))
 	runtime.KeepAlive(pwh)
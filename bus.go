@@ -0,0 +1,84 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// Bus is a group of players with its own volume, e.g. the music or the sound effects.
+//
+// The players routed to a bus are mixed together, multiplied by the bus's volume, and then mixed into the context's output.
+// A player is routed to a bus by Bus of NewPlayerOptions.
+//
+// A bus lives as long as its context.
+//
+// All the functions of a Bus are concurrent-safe.
+type Bus struct {
+	context *Context
+	bus     *mux.Bus
+}
+
+// NewBus creates a new bus with volume 1.
+//
+// NewBus is concurrent-safe.
+func (c *Context) NewBus() *Bus {
+	return &Bus{
+		context: c,
+		bus:     c.mux.NewBus(),
+	}
+}
+
+// Volume returns the volume of the bus in the range of [0, MaxVolume].
+// The default volume is 1.
+func (b *Bus) Volume() float64 {
+	return b.bus.Volume()
+}
+
+// SetVolume sets the volume of the bus. The volume is clamped to the range of [0, MaxVolume].
+//
+// A volume change is applied smoothly not to make a click noise.
+func (b *Bus) SetVolume(volume float64) {
+	b.bus.SetVolume(volume)
+}
+
+// VolumeDB returns the volume of the bus in decibels.
+// If the volume is 0, VolumeDB returns negative infinity.
+func (b *Bus) VolumeDB() float64 {
+	return volumeToDB(b.Volume())
+}
+
+// SetVolumeDB sets the volume of the bus in decibels.
+// 0 dB is the volume 1, and negative infinity is the volume 0.
+func (b *Bus) SetVolumeDB(db float64) {
+	b.SetVolume(dbToVolume(db))
+}
+
+// Mute mutes the bus. The volume is kept and restored by Unmute.
+//
+// The players of a muted bus keep consuming their sources at the normal pace, but their samples are not mixed.
+func (b *Bus) Mute() {
+	b.bus.SetMuted(true)
+}
+
+// Unmute unmutes the bus.
+func (b *Bus) Unmute() {
+	b.bus.SetMuted(false)
+}
+
+// IsMuted reports whether the bus is muted.
+func (b *Bus) IsMuted() bool {
+	return b.bus.IsMuted()
+}
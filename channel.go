@@ -0,0 +1,164 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"fmt"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// ChannelMask is a set of speaker positions.
+//
+// The channels of a frame are ordered by the bits of the positions from the lowest.
+// For example, the channels of ChannelMaskSurround51 are ordered as
+// front left, front right, front center, low frequency, back left, and back right.
+// The bits are the same as WAVEFORMATEXTENSIBLE's dwChannelMask on Windows.
+type ChannelMask uint32
+
+const (
+	ChannelFrontLeft          ChannelMask = ChannelMask(mux.ChannelFrontLeft)
+	ChannelFrontRight         ChannelMask = ChannelMask(mux.ChannelFrontRight)
+	ChannelFrontCenter        ChannelMask = ChannelMask(mux.ChannelFrontCenter)
+	ChannelLowFrequency       ChannelMask = ChannelMask(mux.ChannelLowFrequency)
+	ChannelBackLeft           ChannelMask = ChannelMask(mux.ChannelBackLeft)
+	ChannelBackRight          ChannelMask = ChannelMask(mux.ChannelBackRight)
+	ChannelFrontLeftOfCenter  ChannelMask = ChannelMask(mux.ChannelFrontLeftOfCenter)
+	ChannelFrontRightOfCenter ChannelMask = ChannelMask(mux.ChannelFrontRightOfCenter)
+	ChannelBackCenter         ChannelMask = ChannelMask(mux.ChannelBackCenter)
+	ChannelSideLeft           ChannelMask = ChannelMask(mux.ChannelSideLeft)
+	ChannelSideRight          ChannelMask = ChannelMask(mux.ChannelSideRight)
+)
+
+const (
+	// ChannelMaskMono is the default speaker positions for 1 channel.
+	ChannelMaskMono = ChannelMask(mux.ChannelMaskMono)
+
+	// ChannelMaskStereo is the default speaker positions for 2 channels.
+	ChannelMaskStereo = ChannelMask(mux.ChannelMaskStereo)
+
+	// ChannelMaskQuad is the default speaker positions for 4 channels.
+	ChannelMaskQuad = ChannelMask(mux.ChannelMaskQuad)
+
+	// ChannelMaskSurround51 is the default speaker positions for 6 channels.
+	ChannelMaskSurround51 = ChannelMask(mux.ChannelMaskSurround51)
+
+	// ChannelMaskSurround71 is the default speaker positions for 8 channels.
+	ChannelMaskSurround71 = ChannelMask(mux.ChannelMaskSurround71)
+)
+
+// flattenChannelMatrix converts a channel matrix of NewPlayerOptions to the mux's layout.
+//
+// flattenChannelMatrix returns an error if the matrix doesn't have dstChannelCount rows of srcChannelCount elements.
+func flattenChannelMatrix(matrix [][]float32, dstChannelCount int, srcChannelCount int) ([]float32, error) {
+	if matrix == nil {
+		return nil, nil
+	}
+	if len(matrix) != dstChannelCount {
+		return nil, fmt.Errorf("oto: the channel matrix must have %d rows but %d", dstChannelCount, len(matrix))
+	}
+	m := make([]float32, len(matrix)*srcChannelCount)
+	for i, row := range matrix {
+		if len(row) != srcChannelCount {
+			return nil, fmt.Errorf("oto: the channel matrix's row %d must have %d elements but %d", i, srcChannelCount, len(row))
+		}
+		copy(m[i*srcChannelCount:(i+1)*srcChannelCount], row)
+	}
+	return m, nil
+}
+
+// ChannelPosition is the position of a channel.
+//
+// Unlike ChannelMask, a list of ChannelPosition can specify channels in an arbitrary order and auxiliary channels
+// that don't have a speaker position.
+type ChannelPosition int
+
+const (
+	ChannelPositionMono ChannelPosition = iota
+	ChannelPositionFrontLeft
+	ChannelPositionFrontRight
+	ChannelPositionFrontCenter
+	ChannelPositionLowFrequency
+	ChannelPositionBackLeft
+	ChannelPositionBackRight
+	ChannelPositionFrontLeftOfCenter
+	ChannelPositionFrontRightOfCenter
+	ChannelPositionBackCenter
+	ChannelPositionSideLeft
+	ChannelPositionSideRight
+	ChannelPositionTopCenter
+	ChannelPositionTopFrontLeft
+	ChannelPositionTopFrontRight
+	ChannelPositionTopFrontCenter
+	ChannelPositionTopBackLeft
+	ChannelPositionTopBackRight
+	ChannelPositionTopBackCenter
+)
+
+// ChannelPositionAux0 is the first auxiliary channel.
+// The i-th auxiliary channel is ChannelPositionAux0 + i, where i is in the range of [0, 31].
+const ChannelPositionAux0 ChannelPosition = 64
+
+// channelPositionMasks is the speaker positions corresponding to channel positions.
+var channelPositionMasks = map[ChannelPosition]ChannelMask{
+	ChannelPositionFrontLeft:          ChannelFrontLeft,
+	ChannelPositionFrontRight:         ChannelFrontRight,
+	ChannelPositionFrontCenter:        ChannelFrontCenter,
+	ChannelPositionLowFrequency:       ChannelLowFrequency,
+	ChannelPositionBackLeft:           ChannelBackLeft,
+	ChannelPositionBackRight:          ChannelBackRight,
+	ChannelPositionFrontLeftOfCenter:  ChannelFrontLeftOfCenter,
+	ChannelPositionFrontRightOfCenter: ChannelFrontRightOfCenter,
+	ChannelPositionBackCenter:         ChannelBackCenter,
+	ChannelPositionSideLeft:           ChannelSideLeft,
+	ChannelPositionSideRight:          ChannelSideRight,
+}
+
+// channelPositionsToMask returns the speaker positions of the channel positions.
+//
+// channelPositionsToMask returns 0 if a channel has no speaker position, or the channels are not in the order of ChannelMask.
+// In this case, the sources' channels are mapped to the channels at the same index.
+func channelPositionsToMask(positions []ChannelPosition) ChannelMask {
+	if len(positions) == 1 && positions[0] == ChannelPositionMono {
+		return ChannelMaskMono
+	}
+	var mask, last ChannelMask
+	for _, p := range positions {
+		c, ok := channelPositionMasks[p]
+		if !ok || c <= last {
+			return 0
+		}
+		mask |= c
+		last = c
+	}
+	return mask
+}
+
+// channelMaskToPositions returns the channel positions of the speaker positions.
+func channelMaskToPositions(mask ChannelMask) []ChannelPosition {
+	if mask == ChannelMaskMono {
+		return []ChannelPosition{ChannelPositionMono}
+	}
+	var positions []ChannelPosition
+	for _, c := range mux.ChannelMask(mask).Channels() {
+		for p, m := range channelPositionMasks {
+			if m == ChannelMask(c) {
+				positions = append(positions, p)
+				break
+			}
+		}
+	}
+	return positions
+}
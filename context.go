@@ -15,29 +15,75 @@
 package oto
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ebitengine/oto/v3/internal/mux"
 )
 
+// contextCreated reports whether a context is created on the platforms supporting only one context.
 var (
 	contextCreated       bool
 	contextCreationMutex sync.Mutex
 )
 
+// markSingleContextCreated marks the only context as created, or returns an error if a context is already created.
+//
+// markSingleContextCreated is called by the drivers whose native APIs have a global state,
+// and support only one context at the same time.
+func markSingleContextCreated() error {
+	contextCreationMutex.Lock()
+	defer contextCreationMutex.Unlock()
+
+	if contextCreated {
+		return fmt.Errorf("oto: context is already created")
+	}
+	contextCreated = true
+	return nil
+}
+
+// unmarkSingleContextCreated marks the only context as closed so that a new context can be created.
+func unmarkSingleContextCreated() {
+	contextCreationMutex.Lock()
+	defer contextCreationMutex.Unlock()
+	contextCreated = false
+}
+
 // Context is the main object in Oto. It interacts with the audio drivers.
 //
 // To play sound with Oto, first create a context. Then use the context to create
 // an arbitrary number of players. Then use the players to play sound.
 //
-// Creating multiple contexts is NOT supported.
+// Multiple contexts can be created, e.g. for different sample rates, except on Android, Nintendo Switch and PlayStation 5,
+// whose audio APIs support only one output in a process. On these platforms, creating a second context fails,
+// while contexts with a VirtualDevice or a custom Driver and offline contexts can always be created.
 type Context struct {
-	context *context
+	driver driver
+	mux    *mux.Mux
+
+	sampleRate   int
+	channelCount int
+	channelMask  ChannelMask
+	format       Format
+
+	closed atomic.Bool
 }
 
+// driver is the audio driver of a context.
+type driver interface {
+	Suspend() error
+	Resume() error
+	Err() error
+	Close() error
+}
+
+// errContextClosed is the error returned after the context is closed.
+var errContextClosed = errors.New("oto: the context is closed")
+
 // Format is the format of sources.
 type Format int
 
@@ -52,6 +98,27 @@ const (
 	FormatSignedInt16LE
 )
 
+// MediaRole is the role of the audio. Desktop policies use the role to treat the audio,
+// e.g. to lower the volume of music during a call.
+type MediaRole int
+
+const (
+	// MediaRoleDefault is the unspecified role.
+	MediaRoleDefault MediaRole = iota
+
+	// MediaRoleGame is the role of game audio.
+	MediaRoleGame
+
+	// MediaRoleMusic is the role of music.
+	MediaRoleMusic
+
+	// MediaRoleVideo is the role of the audio of videos.
+	MediaRoleVideo
+
+	// MediaRoleEvent is the role of event sounds, e.g. notifications.
+	MediaRoleEvent
+)
+
 // NewContextOptions represents options for NewContext.
 type NewContextOptions struct {
 	// SampleRate specifies the number of samples that should be played during one second.
@@ -60,9 +127,30 @@ type NewContextOptions struct {
 	SampleRate int
 
 	// ChannelCount specifies the number of channels. One channel is mono playback. Two
-	// channels are stereo playback. No other values are supported.
+	// channels are stereo playback. Six channels are 5.1 surround, and eight channels are 7.1 surround.
+	//
+	// Whether more than two channels are supported depends on the platform.
+	// On Linux, if the device has fewer channels, the output is downmixed to the device's channels.
 	ChannelCount int
 
+	// ChannelMask specifies the speaker positions of the channels.
+	// The number of the positions must match ChannelCount.
+	//
+	// If ChannelMask is 0, the default positions for ChannelCount are used.
+	// See ChannelMaskMono, ChannelMaskStereo, ChannelMaskQuad, ChannelMaskSurround51 and ChannelMaskSurround71.
+	ChannelMask ChannelMask
+
+	// ChannelPositions specifies the positions of the channels explicitly in the order of the channels.
+	// The length must match ChannelCount. If ChannelPositions is not nil, ChannelMask is ignored.
+	//
+	// ChannelPositions can specify an arbitrary order and auxiliary channels, e.g. for multichannel installations.
+	// If the positions are not in the order of ChannelMask or include positions that ChannelMask doesn't have,
+	// sources are not mixed by speaker positions, and a source's channel is played at the channel at the same index.
+	// Use NewPlayerOptions.ChannelMatrix to route sources in this case.
+	//
+	// ChannelPositions is respected only on Linux (PulseAudio) so far.
+	ChannelPositions []ChannelPosition
+
 	// Format specifies the format of sources.
 	Format Format
 
@@ -77,35 +165,155 @@ type NewContextOptions struct {
 	// ApplicationName specifies the name of the client application.
 	// It is used for PulseAudio's volume control UI and so on.
 	ApplicationName string
+
+	// IconName specifies the icon of the client application by an XDG icon name.
+	// It is used for PulseAudio's volume control UI and so on.
+	//
+	// IconName is respected only on Linux (PulseAudio) so far.
+	IconName string
+
+	// MediaName specifies the name of the audio stream. It is used for PulseAudio's volume control UI and so on.
+	// If MediaName is empty, ApplicationName is used.
+	//
+	// MediaName is respected only on Linux (PulseAudio) so far.
+	MediaName string
+
+	// MediaRole specifies the role of the audio.
+	//
+	// MediaRole is respected only on Linux (PulseAudio) so far.
+	MediaRole MediaRole
+
+	// ServerAddress specifies the address of the audio server, e.g. to connect to the host's server from a container.
+	// On Linux, ServerAddress is a PulseAudio server string like "unix:/run/user/1000/pulse/native" or "tcp:localhost:4713".
+	//
+	// If ServerAddress is empty, the default server is used. On Linux, the PULSE_SERVER environment variable is respected.
+	//
+	// On Linux, the authentication cookie is read from the file specified by the PULSE_COOKIE environment variable,
+	// or from the default path.
+	//
+	// ServerAddress is respected only on Linux (PulseAudio) so far.
+	ServerAddress string
+
+	// DeviceID specifies the ID of the output device. See ListOutputDevices for the available devices.
+	//
+	// If DeviceID is empty, the default output device is used.
+	//
+	// DeviceID is respected only on Linux (PulseAudio) so far.
+	DeviceID string
+
+	// FollowDefaultDevice specifies whether the output moves to the default output device when the default changes,
+	// e.g. when headphones are plugged in. The players keep playing at their positions.
+	//
+	// FollowDefaultDevice is ignored while a device is specified by DeviceID or Context.SetOutputDevice.
+	//
+	// FollowDefaultDevice is respected only on Linux (PulseAudio) so far.
+	FollowDefaultDevice bool
+
+	// OnDeviceEvent is called when an event of the output device happens, e.g. when the output moves to another device.
+	//
+	// OnDeviceEvent is called on a goroutine of the audio driver, and must not block.
+	//
+	// On Linux, the output device is checked periodically only when FollowDefaultDevice or OnDeviceEvent is specified,
+	// as the check requires round trips to the server.
+	OnDeviceEvent func(event DeviceEvent)
+
+	// VirtualDevice specifies the virtual device to output to instead of the platform's audio device.
+	// The context plays only when VirtualDevice.Advance is called. This is useful for tests without sound hardware.
+	//
+	// BufferSize is ignored for a virtual device.
+	VirtualDevice *VirtualDevice
+
+	// Driver specifies the audio driver to use instead of the platform's audio driver.
+	// If VirtualDevice is specified, Driver is ignored.
+	Driver Driver
 }
 
 // NewContext creates a new context with given options.
 // A context creates and holds ready-to-use Player objects.
 // NewContext returns a context, a channel that is closed when the context is ready, and an error if it exists.
 //
-// Creating multiple contexts is NOT supported.
+// See Context for the platforms where creating multiple contexts is not supported.
 func NewContext(options *NewContextOptions) (*Context, chan struct{}, error) {
-	contextCreationMutex.Lock()
-	defer contextCreationMutex.Unlock()
+	channelMask, channelPositions, err := channelLayout(options)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if contextCreated {
-		return nil, nil, fmt.Errorf("oto: context is already created")
+	config := &DriverConfig{
+		SampleRate:          options.SampleRate,
+		ChannelCount:        options.ChannelCount,
+		ChannelMask:         channelMask,
+		ChannelPositions:    channelPositions,
+		BufferSize:          options.BufferSize,
+		ApplicationName:     options.ApplicationName,
+		IconName:            options.IconName,
+		MediaName:           options.MediaName,
+		MediaRole:           options.MediaRole,
+		ServerAddress:       options.ServerAddress,
+		DeviceID:            options.DeviceID,
+		FollowDefaultDevice: options.FollowDefaultDevice,
+		OnDeviceEvent:       options.OnDeviceEvent,
+		hasOnDeviceEvent:    options.OnDeviceEvent != nil,
+	}
+	if config.OnDeviceEvent == nil {
+		config.OnDeviceEvent = func(DeviceEvent) {}
+	}
+	c := &Context{
+		mux:          mux.NewWithChannelMask(options.SampleRate, options.ChannelCount, mux.Format(options.Format), mux.ChannelMask(channelMask)),
+		sampleRate:   options.SampleRate,
+		channelCount: options.ChannelCount,
+		channelMask:  channelMask,
+		format:       options.Format,
 	}
-	contextCreated = true
 
-	var bufferSizeInBytes int
-	if options.BufferSize != 0 {
-		// The underlying driver always uses 32bit floats.
-		bytesPerSample := options.ChannelCount * 4
-		bytesPerSecond := options.SampleRate * bytesPerSample
-		bufferSizeInBytes = int(int64(options.BufferSize) * int64(bytesPerSecond) / int64(time.Second))
-		bufferSizeInBytes = bufferSizeInBytes / bytesPerSample * bytesPerSample
+	switch {
+	case options.VirtualDevice != nil:
+		if err := options.VirtualDevice.attach(c.mux, options.ChannelCount); err != nil {
+			return nil, nil, err
+		}
+		c.driver = virtualDriver{device: options.VirtualDevice}
+		ready := make(chan struct{})
+		close(ready)
+		return c, ready, nil
+
+	case options.Driver != nil:
+		if err := options.Driver.Open(config, c.mux.ReadFloat32s); err != nil {
+			return nil, nil, err
+		}
+		c.driver = options.Driver
+		ready := make(chan struct{})
+		close(ready)
+		return c, ready, nil
 	}
-	ctx, ready, err := newContext(options.SampleRate, options.ChannelCount, mux.Format(options.Format), bufferSizeInBytes, options.ApplicationName)
+
+	ctx, ready, err := newContext(c.mux, config)
 	if err != nil {
 		return nil, nil, err
 	}
-	return &Context{context: ctx}, ready, nil
+	c.driver = ctx
+	return c, ready, nil
+}
+
+// channelLayout returns the channel mask and the channel positions for the options.
+func channelLayout(options *NewContextOptions) (ChannelMask, []ChannelPosition, error) {
+	channelMask := options.ChannelMask
+	channelPositions := options.ChannelPositions
+	if channelPositions != nil {
+		if len(channelPositions) != options.ChannelCount {
+			return 0, nil, fmt.Errorf("oto: channel positions don't match the channel count: %d", options.ChannelCount)
+		}
+		return channelPositionsToMask(channelPositions), channelPositions, nil
+	}
+	if channelMask == 0 {
+		channelMask = ChannelMask(mux.DefaultChannelMask(options.ChannelCount))
+	}
+	if channelMask != 0 && mux.ChannelMask(channelMask).Count() != options.ChannelCount {
+		return 0, nil, fmt.Errorf("oto: channel mask doesn't match the channel count: %d", options.ChannelCount)
+	}
+	if channelMask != 0 {
+		channelPositions = channelMaskToPositions(channelMask)
+	}
+	return channelMask, channelPositions, nil
 }
 
 // NewPlayer creates a new, ready-to-use Player belonging to the Context.
@@ -126,7 +334,7 @@ func NewContext(options *NewContextOptions) (*Context, chan struct{}, error) {
 // If you want to clear the underlying buffer for some reasons e.g., you want to seek the position of r,
 // call the player's Reset function.
 //
-// You cannot share r by multiple players.
+// You cannot share r by multiple players. Use a SoundBuffer to share decoded data.
 //
 // The returned player implements Player, BufferSizeSetter, and io.Seeker.
 // You can modify the buffer size of a player by the SetBufferSize function.
@@ -135,12 +343,220 @@ func NewContext(options *NewContextOptions) (*Context, chan struct{}, error) {
 //
 // If r does not implement io.Seeker, the returned player's Seek returns an error.
 //
+// If r implements SampleSource, the player reads float32 values by ReadSamples instead of Read.
+// This skips decoding bytes.
+//
 // NewPlayer is concurrent-safe.
 //
 // All the functions of a Player returned by NewPlayer are concurrent-safe.
 func (c *Context) NewPlayer(r io.Reader) *Player {
 	return &Player{
-		player: c.context.mux.NewPlayer(r),
+		player: c.mux.NewPlayer(r),
+	}
+}
+
+// NewPlayerOptions represents options for NewPlayerWithOptions.
+type NewPlayerOptions struct {
+	// SampleRate specifies the sample rate of the source.
+	// The source is resampled to the context's sample rate if they differ.
+	//
+	// If SampleRate is 0, the context's sample rate is used.
+	SampleRate int
+
+	// ChannelCount specifies the number of channels of the source.
+	// The source is converted to the context's channel count if they differ.
+	//
+	// If ChannelCount is 0, the context's channel count is used.
+	ChannelCount int
+
+	// Format specifies the format of the source.
+	// Format is ignored when the source implements SampleSource.
+	//
+	// If Format is nil, the context's format is used.
+	Format *Format
+
+	// ChannelMask specifies the speaker positions of the source's channels.
+	// The source's channels are routed to the context's speakers at the same positions,
+	// or mixed to the nearest speakers if the context doesn't have them.
+	// For example, a mono source with ChannelFrontCenter is played only at the center speaker in a 5.1 context.
+	//
+	// If ChannelMask is 0, the default positions for the channel count are used.
+	// Note that without ChannelMask, a mono source is played at all the speakers in a stereo context.
+	ChannelMask ChannelMask
+
+	// ChannelMatrix specifies the mixing matrix from the source's channels to the context's channels.
+	// ChannelMatrix[i][j] is the gain from the source's j-th channel to the context's i-th channel.
+	// ChannelMatrix must have as many rows as the context's channels, and each row must have as many elements as the source's channels.
+	//
+	// If ChannelMatrix is not nil, ChannelMask is ignored.
+	ChannelMatrix [][]float32
+
+	// BufferSize specifies the duration of the player's underlying buffer.
+	//
+	// If 0 is specified, the default buffer size is used.
+	BufferSize time.Duration
+
+	// Volume specifies the initial volume.
+	//
+	// If Volume is nil, 1 is used.
+	Volume *float64
+
+	// Loop specifies whether the player rewinds the source to the start at its end and keeps playing.
+	// Loop requires the source to implement io.Seeker. Otherwise, the source is played only once.
+	Loop bool
+
+	// Bus specifies the bus the player is routed to. Bus must be created by the same context.
+	//
+	// If Bus is nil, the player is mixed into the context's output directly.
+	Bus *Bus
+
+	// Play specifies whether the player starts playing immediately.
+	// If Play is false, the player is paused until Play is called.
+	Play bool
+}
+
+// NewPlayerWithOptions creates a new, ready-to-use Player belonging to the Context with the given options.
+//
+// NewPlayerWithOptions works like NewPlayer, but all the settings are applied before the player can play.
+// options can be nil. In this case, NewPlayerWithOptions works exactly like NewPlayer.
+//
+// NewPlayerWithOptions returns an error if options are invalid, e.g. a negative sample rate, a channel matrix of a wrong size,
+// or a bus of another context.
+//
+// NewPlayerWithOptions is concurrent-safe.
+func (c *Context) NewPlayerWithOptions(r io.Reader, options *NewPlayerOptions) (*Player, error) {
+	if options == nil {
+		return c.NewPlayer(r), nil
+	}
+
+	op, err := c.sourceOptions(options.SampleRate, options.ChannelCount, options.Format, options.ChannelMask)
+	if err != nil {
+		return nil, err
+	}
+	op.Volume = 1
+	op.Loop = options.Loop
+	op.Bus, err = c.muxBus(options.Bus)
+	if err != nil {
+		return nil, err
+	}
+	op.ChannelMatrix, err = flattenChannelMatrix(options.ChannelMatrix, c.channelCount, op.ChannelCount)
+	if err != nil {
+		return nil, err
+	}
+	if options.BufferSize < 0 {
+		return nil, fmt.Errorf("oto: the buffer size must not be negative: %v", options.BufferSize)
+	}
+	if options.BufferSize != 0 {
+		bytesPerFrame := op.ChannelCount * op.Format.ByteLength()
+		op.BufferSize = int(int64(options.BufferSize)*int64(op.SampleRate)/int64(time.Second)) * bytesPerFrame
+	}
+	if options.Volume != nil {
+		op.Volume = *options.Volume
+	}
+
+	p := &Player{
+		player: c.mux.NewPlayerWithOptions(r, op),
+	}
+	if options.Play {
+		p.Play()
+	}
+	return p, nil
+}
+
+// muxBus returns the mux's bus of b, or nil if b is nil.
+// muxBus returns an error if b belongs to another context.
+func (c *Context) muxBus(b *Bus) (*mux.Bus, error) {
+	if b == nil {
+		return nil, nil
+	}
+	if b.context != c {
+		return nil, errors.New("oto: the bus belongs to another context")
+	}
+	return b.bus, nil
+}
+
+// sourceOptions returns the mux's options for a source with the given properties.
+// Zero values and a nil format are replaced with the context's.
+func (c *Context) sourceOptions(sampleRate int, channelCount int, format *Format, channelMask ChannelMask) (*mux.PlayerOptions, error) {
+	if sampleRate < 0 {
+		return nil, fmt.Errorf("oto: the sample rate must not be negative: %d", sampleRate)
+	}
+	if channelCount < 0 {
+		return nil, fmt.Errorf("oto: the channel count must not be negative: %d", channelCount)
+	}
+
+	op := &mux.PlayerOptions{
+		SampleRate:   sampleRate,
+		ChannelCount: channelCount,
+		Format:       mux.Format(c.format),
+		ChannelMask:  mux.ChannelMask(channelMask),
+	}
+	if format != nil {
+		switch *format {
+		case FormatFloat32LE, FormatUnsignedInt8, FormatSignedInt16LE:
+		default:
+			return nil, fmt.Errorf("oto: unexpected format: %d", *format)
+		}
+		op.Format = mux.Format(*format)
+	}
+	if op.SampleRate == 0 {
+		op.SampleRate = c.sampleRate
+	}
+	if op.ChannelCount == 0 {
+		op.ChannelCount = c.channelCount
+		if op.ChannelMask == 0 {
+			op.ChannelMask = mux.ChannelMask(c.channelMask)
+		}
+	}
+	if op.ChannelMask != 0 && op.ChannelMask.Count() != op.ChannelCount {
+		return nil, fmt.Errorf("oto: channel mask doesn't match the channel count: %d", op.ChannelCount)
+	}
+	return op, nil
+}
+
+// SampleSource is a source of samples as float32 values.
+//
+// The format of the samples is as follows:
+//
+//	[data]      = [sample 1] [sample 2] [sample 3] ...
+//	[sample *]  = [channel 1] [channel 2] ...
+//
+// Each value is in the range of [-1, 1], regardless of the context's format.
+type SampleSource interface {
+	// ReadSamples reads up to len(buf) values to buf.
+	// ReadSamples returns io.EOF at the end of the source.
+	ReadSamples(buf []float32) (int, error)
+}
+
+// NewPlayerFromSampleSource creates a new, ready-to-use Player reading float32 values from src.
+//
+// This works like NewPlayer except that no bytes are decoded.
+// You cannot share src by multiple players.
+//
+// If src does not implement io.Seeker, the returned player's Seek returns an error.
+//
+// NewPlayerFromSampleSource is concurrent-safe.
+func (c *Context) NewPlayerFromSampleSource(src SampleSource) *Player {
+	return &Player{
+		player: c.mux.NewPlayerFromSampleSource(src),
+	}
+}
+
+// NewPlayerFromRenderFunc creates a new, ready-to-use Player whose samples are generated by render.
+//
+// render is called on the audio rendering path every time the driver requests data while the player is playing.
+// buf is zero-cleared in advance, and render should fill buf with samples in the same layout as SampleSource.
+// As the player doesn't have an underlying buffer, the latency is as low as the driver's.
+//
+// render must return quickly. Blocking render causes glitch noises of all the players.
+// render is never called concurrently.
+//
+// The returned player never finishes by itself. Its Seek always returns an error.
+//
+// NewPlayerFromRenderFunc is concurrent-safe.
+func (c *Context) NewPlayerFromRenderFunc(render func(buf []float32)) *Player {
+	return &Player{
+		player: c.mux.NewPlayerFromRenderFunc(render),
 	}
 }
 
@@ -148,21 +564,119 @@ func (c *Context) NewPlayer(r io.Reader) *Player {
 //
 // Suspend is concurrent-safe.
 func (c *Context) Suspend() error {
-	return c.context.Suspend()
+	if c.closed.Load() {
+		return errContextClosed
+	}
+	return c.driver.Suspend()
 }
 
 // Resume resumes the entire audio play, which was suspended by Suspend.
 //
 // Resume is concurrent-safe.
 func (c *Context) Resume() error {
-	return c.context.Resume()
+	if c.closed.Load() {
+		return errContextClosed
+	}
+	return c.driver.Resume()
 }
 
 // Err returns the current error.
+// After the context is closed, Err returns an error.
+//
+// On Linux, a lost connection to the audio server is not an error, as the context connects to the server again automatically.
+// See DeviceEventDisconnected and DeviceEventReconnected.
 //
 // Err is concurrent-safe.
 func (c *Context) Err() error {
-	return c.context.Err()
+	if c.closed.Load() {
+		return errContextClosed
+	}
+	return c.driver.Err()
+}
+
+// Close closes the context. Close stops the output, closes all the players and the output taps,
+// and releases the resources of the audio driver.
+//
+// After Close, the players of the context can no longer play, and a new context can be created.
+// On Nintendo Switch and PlayStation 5, a new context still cannot be created as the audio cannot be closed.
+//
+// Calling Close more than once does nothing.
+//
+// Close is concurrent-safe.
+func (c *Context) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	// Stop the driver first not to render the players being closed.
+	err := c.driver.Close()
+	if err0 := c.mux.Close(); err0 != nil && err == nil {
+		err = err0
+	}
+	return err
+}
+
+// Volume returns the master volume of the context in the range of [0, MaxVolume].
+// The default volume is 1.
+//
+// Volume is concurrent-safe.
+func (c *Context) Volume() float64 {
+	return c.mux.Volume()
+}
+
+// SetVolume sets the master volume of the context.
+// The master volume is multiplied to the mixed output of all the players.
+// The volume is clamped to the range of [0, MaxVolume].
+//
+// A volume change is applied smoothly not to make a click noise.
+//
+// SetVolume is concurrent-safe.
+func (c *Context) SetVolume(volume float64) {
+	c.mux.SetVolume(volume)
+}
+
+// VolumeDB returns the master volume of the context in decibels.
+// If the volume is 0, VolumeDB returns negative infinity.
+//
+// VolumeDB is concurrent-safe.
+func (c *Context) VolumeDB() float64 {
+	return volumeToDB(c.Volume())
+}
+
+// SetVolumeDB sets the master volume of the context in decibels.
+// 0 dB is the volume 1, and negative infinity is the volume 0.
+//
+// SetVolumeDB is concurrent-safe.
+func (c *Context) SetVolumeDB(db float64) {
+	c.SetVolume(dbToVolume(db))
+}
+
+// Mute mutes the output of the context. The master volume is kept and restored by Unmute.
+//
+// Mute is concurrent-safe.
+func (c *Context) Mute() {
+	c.mux.SetMuted(true)
+}
+
+// Unmute unmutes the output of the context.
+//
+// Unmute is concurrent-safe.
+func (c *Context) Unmute() {
+	c.mux.SetMuted(false)
+}
+
+// IsMuted reports whether the output of the context is muted.
+//
+// IsMuted is concurrent-safe.
+func (c *Context) IsMuted() bool {
+	return c.mux.IsMuted()
+}
+
+// durationToBytes converts the duration to the byte size of the sources' data, aligned to whole samples.
+func (c *Context) durationToBytes(d time.Duration) int {
+	bytesPerSample := c.channelCount * mux.Format(c.format).ByteLength()
+	bytesPerSecond := c.sampleRate * bytesPerSample
+	n := int(int64(d) * int64(bytesPerSecond) / int64(time.Second))
+	return n / bytesPerSample * bytesPerSample
 }
 
 type atomicError struct {
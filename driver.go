@@ -0,0 +1,145 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"time"
+)
+
+// Driver is an audio output backend.
+//
+// Specify a Driver as NewContextOptions.Driver to use it instead of the platform's audio driver,
+// e.g. for a custom backend, a network sink or an engine-specific output.
+//
+// A Driver can also implement SetOutputDevice(id string) error to support Context.SetOutputDevice.
+type Driver interface {
+	// Open starts the output with the configuration.
+	//
+	// The driver pulls the output by calling render with a buffer, which render fills with interleaved float32 samples
+	// of config.ChannelCount channels in the range of [-1, 1].
+	// The length of the buffer must be a multiple of the channel count.
+	// render doesn't allocate memory and doesn't block. render must not be called concurrently.
+	//
+	// Open is called only once by NewContext. If Open returns an error, NewContext returns the error.
+	Open(config *DriverConfig, render func(buf []float32)) error
+
+	// Suspend pauses the output. The driver should stop calling render until Resume is called.
+	Suspend() error
+
+	// Resume resumes the output paused by Suspend.
+	Resume() error
+
+	// Latency returns the duration from rendering samples to playing them.
+	// If the latency is unknown, Latency returns 0.
+	Latency() time.Duration
+
+	// Err returns an error that occurred in the driver, e.g. when the device is lost.
+	Err() error
+
+	// Close stops the output and releases the resources of the driver.
+	// After Close returns, render must not be called.
+	//
+	// Close is called only once by Context.Close.
+	Close() error
+}
+
+// DriverConfig is the configuration of the output for a Driver.
+type DriverConfig struct {
+	// SampleRate is the sample rate of the output.
+	SampleRate int
+
+	// ChannelCount is the number of the channels of the output.
+	ChannelCount int
+
+	// ChannelMask is the speaker positions of the channels.
+	// ChannelMask is 0 if the channels have auxiliary positions or if the positions are unknown.
+	ChannelMask ChannelMask
+
+	// ChannelPositions is the positions of the channels in the order of the channels.
+	// ChannelPositions is nil if the positions are unknown.
+	ChannelPositions []ChannelPosition
+
+	// BufferSize is the requested buffer size of the device.
+	// If BufferSize is 0, the driver's default buffer size should be used.
+	BufferSize time.Duration
+
+	// ApplicationName is the name of the client application.
+	ApplicationName string
+
+	// IconName is the XDG icon name of the client application.
+	IconName string
+
+	// MediaName is the name of the audio stream.
+	MediaName string
+
+	// MediaRole is the role of the audio.
+	MediaRole MediaRole
+
+	// ServerAddress is the address of the audio server.
+	// If ServerAddress is empty, the default server should be used.
+	ServerAddress string
+
+	// DeviceID is the ID of the output device.
+	// If DeviceID is empty, the default output device should be used.
+	DeviceID string
+
+	// FollowDefaultDevice is whether the output should move to the default output device when the default changes.
+	FollowDefaultDevice bool
+
+	// OnDeviceEvent is the function the driver calls when an event of the output device happens.
+	// OnDeviceEvent is never nil.
+	OnDeviceEvent func(event DeviceEvent)
+
+	// hasOnDeviceEvent reports whether OnDeviceEvent is specified by NewContextOptions.
+	// If not, the driver doesn't have to check the output device to report its events.
+	hasOnDeviceEvent bool
+}
+
+// bufferSizeInBytes returns the buffer size in bytes of float32 samples, aligned to whole frames.
+// If the buffer size is not specified, bufferSizeInBytes returns 0.
+func (c *DriverConfig) bufferSizeInBytes() int {
+	if c.BufferSize == 0 {
+		return 0
+	}
+	// The underlying driver always uses 32bit floats.
+	bytesPerSample := c.ChannelCount * 4
+	bytesPerSecond := c.SampleRate * bytesPerSample
+	n := int(int64(c.BufferSize) * int64(bytesPerSecond) / int64(time.Second))
+	return n / bytesPerSample * bytesPerSample
+}
+
+// Latency returns the output latency reported by the driver.
+// If the driver doesn't report the latency, Latency returns 0.
+//
+// The latency of each platform's driver is:
+//
+//   - Linux, FreeBSD and OpenBSD: the sink's latency and the data buffered in the PulseAudio server.
+//     This requires a round trip to the server.
+//   - macOS and iOS: the duration of the audio queue's buffers.
+//   - Windows: the stream's latency and the buffer's duration with WASAPI, or the duration of the queued buffers with WinMM.
+//     Latency returns 0 until the context is ready, or if no device is found.
+//   - Android: the stream's latency, or the buffer's duration if the latency is unavailable, and the data waiting for the stream.
+//   - Browsers: the buffer's duration and the AudioContext's baseLatency and outputLatency if available.
+//   - Nintendo Switch and PlayStation 5: always 0.
+//   - VirtualDevice and OfflineContext: always 0.
+//
+// Latency is concurrent-safe.
+func (c *Context) Latency() time.Duration {
+	l, ok := c.driver.(interface{ Latency() time.Duration })
+	if !ok {
+		return 0
+	}
+	return l.Latency()
+}
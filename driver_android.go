@@ -16,6 +16,7 @@ package oto
 
 import (
 	"sync"
+	"time"
 
 	"github.com/ebitengine/oto/v3/internal/mux"
 	"github.com/ebitengine/oto/v3/internal/oboe"
@@ -24,26 +25,46 @@ import (
 type context struct {
 	mux *mux.Mux
 
+	// ready is closed when the initialization finishes, whether it succeeds or not.
+	ready chan struct{}
+
+	// opened reports whether the stream is opened, and is protected by m.
+	opened bool
+
 	err atomicError
 
 	m sync.Mutex
 }
 
-func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeInBytes int, _ string) (*context, chan struct{}, error) {
+func newContext(m *mux.Mux, config *DriverConfig) (*context, chan struct{}, error) {
+	sampleRate, channelCount := config.SampleRate, config.ChannelCount
+	bufferSizeInBytes := config.bufferSizeInBytes()
+
+	// The audio API has a global state. Only one context is supported.
+	if err := markSingleContextCreated(); err != nil {
+		return nil, nil, err
+	}
+
 	ready := make(chan struct{})
 
 	c := &context{
-		mux: mux.New(sampleRate, channelCount, format),
+		mux:   m,
+		ready: ready,
 	}
 	go func() {
+		defer close(ready)
+
 		c.m.Lock()
 		defer c.m.Unlock()
 
 		if err := oboe.Play(sampleRate, channelCount, c.mux.ReadFloat32s, bufferSizeInBytes); err != nil {
 			c.err.TryStore(err)
+			// The stream might be opened partially. Close it and let another context be created.
+			_ = oboe.Close()
+			unmarkSingleContextCreated()
 			return
 		}
-		close(ready)
+		c.opened = true
 	}()
 	return c, ready, nil
 }
@@ -60,6 +81,32 @@ func (c *context) Resume() error {
 	return oboe.Resume()
 }
 
+// Latency returns the latency of the stream.
+func (c *context) Latency() time.Duration {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return oboe.Latency()
+}
+
 func (c *context) Err() error {
 	return c.err.Load()
 }
+
+func (c *context) Close() error {
+	// Wait for the initialization not to race with opening the stream.
+	<-c.ready
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// If opening the stream failed, the stream is already closed.
+	if !c.opened {
+		return nil
+	}
+	if err := oboe.Close(); err != nil {
+		return err
+	}
+	c.opened = false
+	unmarkSingleContextCreated()
+	return nil
+}
@@ -30,6 +30,7 @@ package oto
 import "C"
 
 import (
+	"time"
 	"unsafe"
 
 	"github.com/ebitengine/oto/v3/internal/mux"
@@ -46,12 +47,20 @@ type context struct {
 
 var theContext *context
 
-func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeInBytes int, _ string) (*context, chan struct{}, error) {
+func newContext(m *mux.Mux, config *DriverConfig) (*context, chan struct{}, error) {
+	sampleRate, channelCount := config.SampleRate, config.ChannelCount
+	bufferSizeInBytes := config.bufferSizeInBytes()
+
+	// The audio API has a global state. Only one context is supported.
+	if err := markSingleContextCreated(); err != nil {
+		return nil, nil, err
+	}
+
 	ready := make(chan struct{})
 	close(ready)
 
 	c := &context{
-		mux: mux.New(sampleRate, channelCount, format),
+		mux: m,
 	}
 	theContext = c
 	C.oto_OpenAudioProxy(C.int(sampleRate), C.int(channelCount), C.int(bufferSizeInBytes))
@@ -69,6 +78,18 @@ func (c *context) Resume() error {
 	return nil
 }
 
+// Latency always returns 0 as the audio API doesn't report the latency.
+func (c *context) Latency() time.Duration {
+	return 0
+}
+
 func (c *context) Err() error {
 	return nil
 }
+
+func (c *context) Close() error {
+	// The audio API doesn't have a function to close the audio.
+	// Keep the audio open, and only stop rendering the mux.
+	// Another context cannot be created as the audio API keeps the first configuration.
+	return nil
+}
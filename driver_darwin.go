@@ -86,17 +86,41 @@ type context struct {
 
 	toPause  bool
 	toResume bool
+	closed   bool
 
-	mux *mux.Mux
-	err atomicError
+	ready chan struct{}
+	mux   *mux.Mux
+	err   atomicError
 }
 
 // TODO: Convert the error code correctly.
 // See https://stackoverflow.com/questions/2196869/how-do-you-convert-an-iphone-osstatus-code-to-something-useful
 
-var theContext *context
+// theContexts is the contexts by their audio queues for the render callback.
+var (
+	theContexts  = map[_AudioQueueRef]*context{}
+	theContextsM sync.Mutex
+)
+
+// setAudioQueue sets the audio queue, and registers the context for the render callback.
+// If q is 0, the context is unregistered.
+func (c *context) setAudioQueue(q _AudioQueueRef) {
+	theContextsM.Lock()
+	defer theContextsM.Unlock()
+
+	if c.audioQueue != 0 {
+		delete(theContexts, c.audioQueue)
+	}
+	c.audioQueue = q
+	if q != 0 {
+		theContexts[q] = c
+	}
+}
+
+func newContext(m *mux.Mux, config *DriverConfig) (*context, chan struct{}, error) {
+	sampleRate, channelCount := config.SampleRate, config.ChannelCount
+	bufferSizeInBytes := config.bufferSizeInBytes()
 
-func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeInBytes int, _ string) (*context, chan struct{}, error) {
 	// defaultOneBufferSizeInBytes is the default buffer size in bytes.
 	//
 	// 12288 seems necessary at least on iPod touch (7th) and MacBook Pro 2020.
@@ -117,12 +141,12 @@ func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeI
 
 	c := &context{
 		cond:                 sync.NewCond(&sync.Mutex{}),
-		mux:                  mux.New(sampleRate, channelCount, format),
+		ready:                ready,
+		mux:                  m,
 		sampleRate:           sampleRate,
 		channelCount:         channelCount,
 		oneBufferSizeInBytes: oneBufferSizeInBytes,
 	}
-	theContext = c
 
 	if err := initializeAPI(); err != nil {
 		return nil, nil, err
@@ -144,7 +168,7 @@ func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeI
 			c.err.TryStore(err)
 			return
 		}
-		c.audioQueue = q
+		c.setAudioQueue(q)
 		c.unqueuedBuffers = bs
 
 		var retryCount int
@@ -189,10 +213,10 @@ func (c *context) wait() bool {
 	c.cond.L.Lock()
 	defer c.cond.L.Unlock()
 
-	for len(c.unqueuedBuffers) == 0 && c.err.Load() == nil && !c.toPause && !c.toResume {
+	for len(c.unqueuedBuffers) == 0 && c.err.Load() == nil && !c.toPause && !c.toResume && !c.closed {
 		c.cond.Wait()
 	}
-	return c.err.Load() == nil
+	return c.err.Load() == nil && !c.closed
 }
 
 func (c *context) loop() {
@@ -209,7 +233,7 @@ func (c *context) appendBuffer(buf32 []float32) {
 	c.cond.L.Lock()
 	defer c.cond.L.Unlock()
 
-	if c.err.Load() != nil {
+	if c.err.Load() != nil || c.closed {
 		return
 	}
 
@@ -345,7 +369,7 @@ func (c *context) rebuildAudioQueue() error {
 		// kAudioQueueErr_QueueInvalidated is expected here — that's the very case
 		// we're recovering from. Anything else is unexpected and worth surfacing.
 		osstatus := _AudioQueueDispose(c.audioQueue, true)
-		c.audioQueue = 0
+		c.setAudioQueue(0)
 		if osstatus != noErr && osstatus != kAudioQueueErr_QueueInvalidated {
 			c.unqueuedBuffers = nil
 			return fmt.Errorf("oto: AudioQueueDispose failed during rebuild: %d", osstatus)
@@ -357,26 +381,61 @@ func (c *context) rebuildAudioQueue() error {
 	if err != nil {
 		return fmt.Errorf("oto: rebuilding AudioQueue failed: %w", err)
 	}
-	c.audioQueue = q
+	c.setAudioQueue(q)
 	c.unqueuedBuffers = bs
 	return nil
 }
 
+// Latency returns the duration of the audio queue's buffers.
+func (c *context) Latency() time.Duration {
+	bytesPerSecond := int64(c.sampleRate) * int64(c.channelCount) * 4
+	return time.Duration(int64(bufferCount*c.oneBufferSizeInBytes) * int64(time.Second) / bytesPerSecond)
+}
+
 func (c *context) Err() error {
 	return c.err.Load()
 }
 
+func (c *context) Close() error {
+	// Wait for the initialization not to race with creating the audio queue.
+	<-c.ready
+
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+
+	c.closed = true
+	c.cond.Signal()
+
+	if c.audioQueue == 0 {
+		return nil
+	}
+	osstatus := _AudioQueueDispose(c.audioQueue, true)
+	c.setAudioQueue(0)
+	c.unqueuedBuffers = nil
+	if osstatus != noErr && osstatus != kAudioQueueErr_QueueInvalidated {
+		return fmt.Errorf("oto: AudioQueueDispose failed at Close: %d", osstatus)
+	}
+	return nil
+}
+
 func render(inUserData unsafe.Pointer, inAQ _AudioQueueRef, inBuffer _AudioQueueBufferRef) {
-	theContext.cond.L.Lock()
-	defer theContext.cond.L.Unlock()
+	theContextsM.Lock()
+	c, ok := theContexts[inAQ]
+	theContextsM.Unlock()
 	// Drop callbacks from a previously-disposed queue: after rebuildAudioQueue,
 	// late-delivered callbacks for the old queue would otherwise inject stale
 	// buffer pointers into c.unqueuedBuffers.
-	if inAQ != theContext.audioQueue {
+	if !ok {
+		return
+	}
+
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	if inAQ != c.audioQueue {
 		return
 	}
-	theContext.unqueuedBuffers = append(theContext.unqueuedBuffers, inBuffer)
-	theContext.cond.Signal()
+	c.unqueuedBuffers = append(c.unqueuedBuffers, inBuffer)
+	c.cond.Signal()
 }
 
 func sleepTime(count int) time.Duration {
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"runtime"
 	"syscall/js"
+	"time"
 	"unsafe"
 
 	"github.com/ebitengine/oto/v3/internal/mux"
@@ -29,11 +30,18 @@ type context struct {
 	scriptProcessor         js.Value
 	scriptProcessorCallback js.Func
 	ready                   bool
+	closed                  bool
+
+	// bufferDuration is the duration of the buffer passed to the audio thread at once.
+	bufferDuration time.Duration
 
 	mux *mux.Mux
 }
 
-func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeInBytes int, _ string) (*context, chan struct{}, error) {
+func newContext(m *mux.Mux, config *DriverConfig) (*context, chan struct{}, error) {
+	sampleRate, channelCount := config.SampleRate, config.ChannelCount
+	bufferSizeInBytes := config.bufferSizeInBytes()
+
 	ready := make(chan struct{})
 
 	class := js.Global().Get("AudioContext")
@@ -48,7 +56,7 @@ func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeI
 
 	d := &context{
 		audioContext: class.New(options),
-		mux:          mux.New(sampleRate, channelCount, format),
+		mux:          m,
 	}
 
 	if bufferSizeInBytes == 0 {
@@ -57,6 +65,7 @@ func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeI
 	}
 
 	buf32 := make([]float32, bufferSizeInBytes/4)
+	d.bufferDuration = time.Duration(int64(bufferSizeInBytes/4/channelCount) * int64(time.Second) / int64(sampleRate))
 
 	if w := d.audioContext.Get("audioWorklet"); w.Truthy() {
 		script := fmt.Sprintf(`
@@ -118,6 +127,9 @@ registerProcessor('oto-worklet-processor', OtoWorkletProcessor);
 			port := node.Get("port")
 			// When the worklet processor requests more data, send the request to the worklet.
 			port.Set("onmessage", js.FuncOf(func(this js.Value, arguments []js.Value) any {
+				if d.closed {
+					return nil
+				}
 				d.mux.ReadFloat32s(buf32)
 				buf := float32SliceToTypedArray(buf32)
 				port.Call("postMessage", buf, map[string]any{
@@ -182,7 +194,7 @@ registerProcessor('oto-worklet-processor', OtoWorkletProcessor);
 		return nil
 	})
 	onEventFired = js.FuncOf(func(this js.Value, arguments []js.Value) any {
-		if !d.ready {
+		if !d.ready && !d.closed {
 			d.audioContext.Call("resume").Call("then", onResumeSuccess)
 		}
 		return nil
@@ -204,10 +216,33 @@ func (c *context) Resume() error {
 	return nil
 }
 
+// Latency returns the duration of the buffer and the latencies reported by the AudioContext.
+// outputLatency is not available on some browsers, e.g. Safari. In this case, only baseLatency is added.
+func (c *context) Latency() time.Duration {
+	latency := c.bufferDuration
+	for _, name := range []string{"baseLatency", "outputLatency"} {
+		if v := c.audioContext.Get(name); v.Type() == js.TypeNumber {
+			latency += time.Duration(v.Float() * float64(time.Second))
+		}
+	}
+	return latency
+}
+
 func (c *context) Err() error {
 	return nil
 }
 
+func (c *context) Close() error {
+	c.closed = true
+	if c.scriptProcessor.Truthy() {
+		c.scriptProcessor.Call("removeEventListener", "audioprocess", c.scriptProcessorCallback)
+		c.scriptProcessor.Call("disconnect")
+		c.scriptProcessorCallback.Release()
+	}
+	c.audioContext.Call("close")
+	return nil
+}
+
 func float32SliceToTypedArray(s []float32) js.Value {
 	bs := unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*4)
 	a := js.Global().Get("Uint8Array").New(len(bs))
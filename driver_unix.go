@@ -17,12 +17,15 @@
 package oto
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/jfreymuth/pulse"
+	"github.com/jfreymuth/pulse/proto"
 
 	"github.com/ebitengine/oto/v3/internal/mux"
 )
@@ -32,22 +35,64 @@ type context struct {
 	stream *pulse.PlaybackStream
 
 	suspended bool
+	closed    bool
 	cond      *sync.Cond
 
+	// deviceID is the ID of the output device specified explicitly. If deviceID is empty, the default device is used.
+	// sinkIndex and sinkName are the sink the stream was last seen on.
+	// watchDevice reports whether the output device is checked periodically.
+	// The check is needed only to follow the default device or to report the device's changes.
+	deviceID      string
+	followDefault bool
+	watchDevice   bool
+	sinkIndex     uint32
+	sinkName      string
+	deviceM       sync.Mutex
+
+	onDeviceEvent func(event DeviceEvent)
+	done          chan struct{}
+	monitorWG     sync.WaitGroup
+
+	// clientOptions, playbackOptions and streamChannelMap are used to connect to the server again after the connection is lost.
+	// streamChannelMap is the stream's channels chosen for the first sink. See pulseStreamChannels.
+	// The stream's channels are kept after reconnecting, as the mux's output channels must not change while rendering.
+	clientOptions    []pulse.ClientOption
+	playbackOptions  []pulse.PlaybackOption
+	streamChannelMap proto.ChannelMap
+
 	mux *mux.Mux
 	err atomicError
 }
 
-func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeInBytes int, applicationName string) (client *context, ready chan struct{}, err error) {
+// deviceMonitorInterval is the interval to check the output device.
+// Checking the device requires round trips to the server, so do this with some time interval.
+const deviceMonitorInterval = 500 * time.Millisecond
+
+// maxReconnectInterval is the maximum interval to retry connecting to the server after the connection is lost.
+const maxReconnectInterval = 10 * time.Second
+
+func newContext(m *mux.Mux, config *DriverConfig) (client *context, ready chan struct{}, err error) {
+	sampleRate, channelCount := config.SampleRate, config.ChannelCount
+	bufferSizeInBytes := config.bufferSizeInBytes()
+	applicationName := config.ApplicationName
+
 	client = &context{
-		cond: sync.NewCond(&sync.Mutex{}),
-		mux:  mux.New(sampleRate, channelCount, format),
+		cond:          sync.NewCond(&sync.Mutex{}),
+		deviceID:      config.DeviceID,
+		followDefault: config.FollowDefaultDevice,
+		watchDevice:   config.FollowDefaultDevice || config.hasOnDeviceEvent,
+		sinkIndex:     proto.Undefined,
+		onDeviceEvent: config.OnDeviceEvent,
+		done:          make(chan struct{}),
+		mux:           m,
 	}
 	ready = make(chan struct{})
 	close(ready)
+	// client is nil when an error is returned. Keep the context to close its connection.
+	c := client
 	defer func() {
-		if client != nil && client.client != nil && err != nil {
-			client.client.Close()
+		if c.client != nil && err != nil {
+			c.client.Close()
 		}
 	}()
 
@@ -59,21 +104,50 @@ func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeI
 		}
 	}
 
-	client.client, err = pulse.NewClient(pulse.ClientApplicationName(applicationName))
+	client.clientOptions = []pulse.ClientOption{
+		pulse.ClientApplicationName(applicationName),
+	}
+	if config.IconName != "" {
+		client.clientOptions = append(client.clientOptions, pulse.ClientApplicationIconName(config.IconName))
+	}
+	if config.ServerAddress != "" {
+		client.clientOptions = append(client.clientOptions, pulse.ClientServerString(config.ServerAddress))
+	}
+	client.client, err = client.newClient()
 	if err != nil {
-		return nil, ready, fmt.Errorf("oto: PulseAudio client initialization failed: %w", err)
+		return nil, ready, err
 	}
 
+	mediaName := config.MediaName
+	if mediaName == "" {
+		mediaName = applicationName
+	}
 	options := []pulse.PlaybackOption{
-		pulse.PlaybackMediaName(applicationName),
+		pulse.PlaybackMediaName(mediaName),
+	}
+	if config.IconName != "" {
+		options = append(options, pulse.PlaybackMediaIconName(config.IconName))
 	}
-	switch channelCount {
-	case 1:
-		options = append(options, pulse.PlaybackMono)
-	case 2:
-		options = append(options, pulse.PlaybackStereo)
-	default:
-		return nil, ready, fmt.Errorf("oto: PulseAudio backend supports only mono or stereo output: %d", channelCount)
+	if role, ok := pulseMediaRoles[config.MediaRole]; ok {
+		options = append(options, pulse.PlaybackRawOption(func(s *proto.CreatePlaybackStream) {
+			s.Properties["media.role"] = proto.PropListString(role)
+		}))
+	}
+
+	// Find the output device to check its channels.
+	var sink *pulse.Sink
+	if config.DeviceID != "" {
+		sink, err = client.client.SinkByID(config.DeviceID)
+		if err != nil {
+			return nil, ready, fmt.Errorf("oto: PulseAudio output device %q is not found: %w", config.DeviceID, err)
+		}
+	} else if s, err := client.client.DefaultSink(); err == nil {
+		sink = s
+	}
+
+	channelMap, err := pulseChannelMap(channelCount, config.ChannelPositions)
+	if err != nil {
+		return nil, ready, err
 	}
 	options = append(options, pulse.PlaybackSampleRate(sampleRate))
 	{
@@ -87,22 +161,314 @@ func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeI
 		options = append(options, pulse.PlaybackLatency(latency))
 	}
 
-	client.stream, err = client.client.NewPlayback(pulse.Float32Reader(client.read), options...)
+	client.playbackOptions = options
+	streamChannelMap, outputChannelCount, outputChannelMask := pulseStreamChannels(channelMap, client.mux.ChannelMask(), sinkChannels(sink))
+	client.streamChannelMap = streamChannelMap
+	client.stream, err = client.newStream(client.client, sink, streamChannelMap)
 	if err != nil {
-		return nil, ready, fmt.Errorf("oto: PulseAudio playback initialization failed: %w", err)
+		return nil, ready, err
 	}
+	client.mux.SetOutputChannels(outputChannelCount, outputChannelMask)
 	client.stream.Start()
 
+	client.monitorWG.Add(1)
+	go client.monitorDevice()
+
 	return client, ready, nil
 }
 
+// pulseMediaRoles is the PulseAudio's media roles corresponding to the media roles.
+var pulseMediaRoles = map[MediaRole]string{
+	MediaRoleGame:  "game",
+	MediaRoleMusic: "music",
+	MediaRoleVideo: "video",
+	MediaRoleEvent: "event",
+}
+
+// newClient connects to the server.
+func (c *context) newClient() (*pulse.Client, error) {
+	return newPulseClient(c.clientOptions...)
+}
+
+// newPulseClient connects to the server with the options.
+func newPulseClient(options ...pulse.ClientOption) (*pulse.Client, error) {
+	client, err := pulse.NewClient(options...)
+	if err != nil {
+		return nil, fmt.Errorf("oto: PulseAudio client initialization failed: %w", err)
+	}
+	return client, nil
+}
+
+// newStream creates a new playback stream reading the mux with the channels.
+// If sink is nil, the server chooses the sink.
+func (c *context) newStream(client *pulse.Client, sink *pulse.Sink, channelMap proto.ChannelMap) (*pulse.PlaybackStream, error) {
+	options := append(c.playbackOptions[:len(c.playbackOptions):len(c.playbackOptions)], pulse.PlaybackChannels(channelMap))
+	if sink != nil {
+		options = append(options, pulse.PlaybackSink(sink))
+	}
+	stream, err := client.NewPlayback(pulse.Float32Reader(c.read), options...)
+	if err != nil {
+		return nil, fmt.Errorf("oto: PulseAudio playback initialization failed: %w", err)
+	}
+	return stream, nil
+}
+
+// monitorDevice checks the output device periodically until the context is closed.
+// The output device is checked only when watchDevice is true.
+//
+// monitorDevice also connects to the server again when the connection is lost, e.g. when the server restarts.
+// The pulse package doesn't notify the loss of the connection, so the loss is checked periodically regardless of watchDevice.
+// Checking the loss doesn't require round trips to the server.
+// The mux is not touched, so the players keep their states.
+func (c *context) monitorDevice() {
+	defer c.monitorWG.Done()
+
+	t := time.NewTicker(deviceMonitorInterval)
+	defer t.Stop()
+
+	var r reconnectState
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-t.C:
+		}
+
+		if c.isConnectionLost() {
+			if r.lose() {
+				c.onDeviceEvent(DeviceEvent{
+					Type: DeviceEventDisconnected,
+				})
+			}
+			// Don't start a new stream while suspended. Retry after resuming.
+			if c.isSuspended() || !r.canRetry(time.Now()) {
+				continue
+			}
+			if err := c.reconnect(); err != nil {
+				r.fail(time.Now())
+				continue
+			}
+			r.succeed()
+
+			// Record the new sink. The sink is reported by the event instead of DeviceEventChanged.
+			var id string
+			if c.watchDevice {
+				id, _, _ = c.checkDevice()
+			}
+			c.onDeviceEvent(DeviceEvent{
+				Type:     DeviceEventReconnected,
+				DeviceID: id,
+			})
+			continue
+		}
+
+		if !c.watchDevice {
+			continue
+		}
+		id, changed, err := c.checkDevice()
+		if err != nil {
+			// The error is reported by the stream if the connection is lost.
+			continue
+		}
+		if changed {
+			c.onDeviceEvent(DeviceEvent{
+				Type:     DeviceEventChanged,
+				DeviceID: id,
+			})
+		}
+	}
+}
+
+// reconnectState is the state of reconnecting to the server after the connection is lost.
+type reconnectState struct {
+	lost          bool
+	retryInterval time.Duration
+	nextRetry     time.Time
+}
+
+// lose records that the connection is lost, and reports whether the connection was alive until now.
+func (r *reconnectState) lose() bool {
+	if r.lost {
+		return false
+	}
+	r.lost = true
+	return true
+}
+
+// canRetry reports whether reconnecting can be tried at now.
+func (r *reconnectState) canRetry(now time.Time) bool {
+	return !now.Before(r.nextRetry)
+}
+
+// fail records that reconnecting failed at now.
+// The next retry is delayed with an exponential backoff, as the server might take time to restart.
+func (r *reconnectState) fail(now time.Time) {
+	r.retryInterval = min(max(r.retryInterval*2, deviceMonitorInterval), maxReconnectInterval)
+	r.nextRetry = now.Add(r.retryInterval)
+}
+
+// succeed records that reconnecting succeeded.
+func (r *reconnectState) succeed() {
+	*r = reconnectState{}
+}
+
+// isConnectionLost reports whether the connection to the server is lost.
+func (c *context) isConnectionLost() bool {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	return errors.Is(c.stream.Error(), pulse.ErrConnectionClosed)
+}
+
+func (c *context) isSuspended() bool {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	return c.suspended
+}
+
+// reconnect connects to the server again, and replaces the client and the stream.
+func (c *context) reconnect() error {
+	c.deviceM.Lock()
+	defer c.deviceM.Unlock()
+
+	client, err := c.newClient()
+	if err != nil {
+		return err
+	}
+
+	// If the specified device is not available anymore, let the server choose the sink.
+	var sink *pulse.Sink
+	if c.deviceID != "" {
+		if s, err := client.SinkByID(c.deviceID); err == nil {
+			sink = s
+		}
+	}
+
+	// The sink might differ from the last one, e.g. the server restarted with another configuration.
+	// Keep the stream's channels anyway, and let the server remix them for the new sink.
+	stream, err := c.newStream(client, sink, c.streamChannelMap)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	c.cond.L.Lock()
+	old := c.client
+	c.client = client
+	c.stream = stream
+	c.cond.L.Unlock()
+	old.Close()
+
+	c.sinkIndex = proto.Undefined
+	c.sinkName = ""
+	stream.Start()
+	return nil
+}
+
+// checkDevice checks the sink the stream is on, and moves the stream to the default sink if needed.
+// checkDevice returns the sink's name and whether the sink has changed since the last check.
+func (c *context) checkDevice() (string, bool, error) {
+	c.deviceM.Lock()
+	defer c.deviceM.Unlock()
+
+	var input proto.GetSinkInputInfoReply
+	if err := c.client.RawRequest(&proto.GetSinkInputInfo{SinkInputIndex: c.stream.StreamInputIndex()}, &input); err != nil {
+		return "", false, err
+	}
+
+	var changed bool
+	if input.SinkIndex != c.sinkIndex {
+		var sink proto.GetSinkInfoReply
+		if err := c.client.RawRequest(&proto.GetSinkInfo{SinkIndex: input.SinkIndex}, &sink); err != nil {
+			return "", false, err
+		}
+		// The first check only records the initial sink.
+		changed = c.sinkIndex != proto.Undefined
+		c.sinkIndex = input.SinkIndex
+		c.sinkName = sink.SinkName
+	}
+
+	if c.followDefault && c.deviceID == "" {
+		var server proto.GetServerInfoReply
+		if err := c.client.RawRequest(&proto.GetServerInfo{}, &server); err != nil {
+			return "", false, err
+		}
+		// The move is detected at the next check.
+		if server.DefaultSinkName != "" && server.DefaultSinkName != c.sinkName {
+			if err := c.moveTo(server.DefaultSinkName); err != nil {
+				return "", false, err
+			}
+		}
+	}
+
+	return c.sinkName, changed, nil
+}
+
+// moveTo moves the stream to the sink with the name.
+//
+// When moveTo is called, the mutex deviceM must be locked.
+func (c *context) moveTo(sinkName string) error {
+	if err := c.client.RawRequest(&proto.MoveSinkInput{
+		SinkInputIndex: c.stream.StreamInputIndex(),
+		DeviceIndex:    proto.Undefined,
+		DeviceName:     sinkName,
+	}, nil); err != nil {
+		return fmt.Errorf("oto: PulseAudio MoveSinkInput failed: %w", err)
+	}
+	return nil
+}
+
+func (c *context) SetOutputDevice(id string) error {
+	c.deviceM.Lock()
+	defer c.deviceM.Unlock()
+
+	sinkName := id
+	if sinkName == "" {
+		var server proto.GetServerInfoReply
+		if err := c.client.RawRequest(&proto.GetServerInfo{}, &server); err != nil {
+			return fmt.Errorf("oto: PulseAudio GetServerInfo failed: %w", err)
+		}
+		sinkName = server.DefaultSinkName
+	}
+	if err := c.moveTo(sinkName); err != nil {
+		return err
+	}
+	c.deviceID = id
+	return nil
+}
+
+// Latency returns the latency reported by the server: the sink's latency and the data buffered in the server.
+// If the server doesn't reply, e.g. while reconnecting, Latency returns the duration of the stream's buffer.
+func (c *context) Latency() time.Duration {
+	c.deviceM.Lock()
+	defer c.deviceM.Unlock()
+
+	// The stream's format is always 32-bit floats.
+	bytesPerSecond := int64(c.stream.SampleRate()) * int64(c.stream.Channels()) * 4
+	now := time.Now()
+	var reply proto.GetPlaybackLatencyReply
+	if err := c.client.RawRequest(&proto.GetPlaybackLatency{
+		StreamIndex: c.stream.StreamIndex(),
+		Time: proto.Time{
+			Seconds:      uint32(now.Unix()),
+			Microseconds: uint32(now.Nanosecond() / 1000),
+		},
+	}, &reply); err != nil {
+		return time.Duration(int64(c.stream.BufferSizeBytes()) * int64(time.Second) / bytesPerSecond)
+	}
+	buffered := max(reply.WriteIndex-reply.ReadIndex, 0)
+	return time.Duration(reply.Latency)*time.Microsecond + time.Duration(buffered*int64(time.Second)/bytesPerSecond)
+}
+
 func (c *context) read(buf []float32) (int, error) {
 	c.cond.L.Lock()
 	defer c.cond.L.Unlock()
 
-	for c.suspended && c.err.Load() == nil {
+	for c.suspended && !c.closed && c.err.Load() == nil {
 		c.cond.Wait()
 	}
+	if c.closed {
+		return 0, pulse.EndOfData
+	}
 	if err := c.err.Load(); err != nil {
 		return 0, err
 	}
@@ -118,8 +484,8 @@ func (c *context) Suspend() error {
 	if err := c.err.Load(); err != nil {
 		return err
 	}
-	if err := c.stream.Error(); err != nil {
-		return fmt.Errorf("oto: PulseAudio error: %w", err)
+	if err := c.streamError(); err != nil {
+		return err
 	}
 
 	c.suspended = true
@@ -134,8 +500,8 @@ func (c *context) Resume() error {
 	if err := c.err.Load(); err != nil {
 		return err
 	}
-	if err := c.stream.Error(); err != nil {
-		return fmt.Errorf("oto: PulseAudio error: %w", err)
+	if err := c.streamError(); err != nil {
+		return err
 	}
 
 	c.suspended = false
@@ -148,8 +514,176 @@ func (c *context) Err() error {
 	if err := c.err.Load(); err != nil {
 		return err
 	}
-	if err := c.stream.Error(); err != nil {
-		return fmt.Errorf("oto: PulseAudio error: %w", err)
+
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	return c.streamError()
+}
+
+// streamError returns the stream's error.
+// streamError returns nil if the connection is lost, as the connection is recovered automatically.
+//
+// When streamError is called, the mutex cond.L must be locked.
+func (c *context) streamError() error {
+	err := c.stream.Error()
+	if err == nil || errors.Is(err, pulse.ErrConnectionClosed) {
+		return nil
 	}
+	return fmt.Errorf("oto: PulseAudio error: %w", err)
+}
+
+func (c *context) Close() error {
+	// Stop rendering before closing the stream, as the stream might be reading.
+	c.cond.L.Lock()
+	c.closed = true
+	c.cond.Signal()
+	c.cond.L.Unlock()
+
+	close(c.done)
+	c.monitorWG.Wait()
+
+	c.stream.Close()
+	c.client.Close()
 	return nil
 }
+
+func listOutputDevices(serverAddress string) ([]OutputDevice, error) {
+	var options []pulse.ClientOption
+	if serverAddress != "" {
+		options = append(options, pulse.ClientServerString(serverAddress))
+	}
+	client, err := newPulseClient(options...)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var server proto.GetServerInfoReply
+	if err := client.RawRequest(&proto.GetServerInfo{}, &server); err != nil {
+		return nil, fmt.Errorf("oto: PulseAudio GetServerInfo failed: %w", err)
+	}
+	var sinks proto.GetSinkInfoListReply
+	if err := client.RawRequest(&proto.GetSinkInfoList{}, &sinks); err != nil {
+		return nil, fmt.Errorf("oto: PulseAudio GetSinkInfoList failed: %w", err)
+	}
+
+	devices := make([]OutputDevice, 0, len(sinks))
+	for _, sink := range sinks {
+		// The sink's name is the identifier, and the sink's description is the human-readable name.
+		d := OutputDevice{
+			ID:      sink.SinkName,
+			Name:    sink.Device,
+			Default: sink.SinkName == server.DefaultSinkName,
+		}
+		for _, port := range sink.Ports {
+			if port.Name == sink.ActivePortName {
+				d.Description = port.Description
+				break
+			}
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// pulseChannelPositions is the PulseAudio's channel positions corresponding to the channel positions.
+var pulseChannelPositions = map[ChannelPosition]byte{
+	ChannelPositionMono:               proto.ChannelMono,
+	ChannelPositionFrontLeft:          proto.ChannelFrontLeft,
+	ChannelPositionFrontRight:         proto.ChannelFrontRight,
+	ChannelPositionFrontCenter:        proto.ChannelFrontCenter,
+	ChannelPositionLowFrequency:       proto.ChannelLFE,
+	ChannelPositionBackLeft:           proto.ChannelRearLeft,
+	ChannelPositionBackRight:          proto.ChannelRearRight,
+	ChannelPositionFrontLeftOfCenter:  proto.ChannelLeftCenter,
+	ChannelPositionFrontRightOfCenter: proto.ChannelRightCenter,
+	ChannelPositionBackCenter:         proto.ChannelRearCenter,
+	ChannelPositionSideLeft:           proto.ChannelLeftSide,
+	ChannelPositionSideRight:          proto.ChannelRightSide,
+	ChannelPositionTopCenter:          proto.ChannelTopCenter,
+	ChannelPositionTopFrontLeft:       proto.ChannelTopFrontLeft,
+	ChannelPositionTopFrontRight:      proto.ChannelTopFrontRight,
+	ChannelPositionTopFrontCenter:     proto.ChannelTopFrontCenter,
+	ChannelPositionTopBackLeft:        proto.ChannelTopRearLeft,
+	ChannelPositionTopBackRight:       proto.ChannelTopRearRight,
+	ChannelPositionTopBackCenter:      proto.ChannelTopRearCenter,
+}
+
+// pulseChannelPosition returns the PulseAudio's channel position for the channel position.
+func pulseChannelPosition(position ChannelPosition) (byte, bool) {
+	if position >= ChannelPositionAux0 && position <= ChannelPositionAux0+31 {
+		return byte(proto.ChannelAux0 + int(position-ChannelPositionAux0)), true
+	}
+	p, ok := pulseChannelPositions[position]
+	return p, ok
+}
+
+// pulseChannelMap returns the PulseAudio's channel map for the channel positions.
+// If positions is nil, the channels are auxiliary channels.
+func pulseChannelMap(channelCount int, positions []ChannelPosition) (proto.ChannelMap, error) {
+	if positions == nil {
+		if channelCount > 32 {
+			return nil, fmt.Errorf("oto: PulseAudio backend supports at most 32 channels without channel positions: %d", channelCount)
+		}
+		for i := range channelCount {
+			positions = append(positions, ChannelPositionAux0+ChannelPosition(i))
+		}
+	}
+	var m proto.ChannelMap
+	for _, position := range positions {
+		p, ok := pulseChannelPosition(position)
+		if !ok {
+			return nil, fmt.Errorf("oto: PulseAudio backend doesn't support the channel position: %d", position)
+		}
+		m = append(m, p)
+	}
+	return m, nil
+}
+
+// pulseStreamChannels returns the channel map of a stream on a sink, and the channels the mux outputs to the stream.
+// channelMap and mask are the context's channels. sinkChannels is the sink's channels, or nil if the sink is unknown.
+//
+// Usually, the stream has the context's channels, and PulseAudio remixes the channels to the sink.
+// If the sink has fewer channels and the channels have speaker positions,
+// the stream has the sink's channels and the mux downmixes the samples instead.
+//
+// pulseStreamChannels is called only when the stream is created first, as the mux's output channels cannot change after that.
+func pulseStreamChannels(channelMap proto.ChannelMap, mask mux.ChannelMask, sinkChannels proto.ChannelMap) (proto.ChannelMap, int, mux.ChannelMask) {
+	if mask != 0 {
+		if m := channelMaskFromPulse(sinkChannels); len(sinkChannels) < len(channelMap) && m != 0 {
+			return sinkChannels, len(sinkChannels), mux.ChannelMask(m)
+		}
+	}
+	return channelMap, len(channelMap), mask
+}
+
+// sinkChannels returns the sink's channels, or nil if sink is nil.
+func sinkChannels(sink *pulse.Sink) proto.ChannelMap {
+	if sink == nil {
+		return nil
+	}
+	return sink.Channels()
+}
+
+// channelMaskFromPulse returns the speaker positions for the PulseAudio's channel map.
+// channelMaskFromPulse returns 0 if the channel map doesn't match any speaker positions.
+func channelMaskFromPulse(channelMap proto.ChannelMap) ChannelMask {
+	var positions []ChannelPosition
+	for _, p := range channelMap {
+		position, ok := channelPositionFromPulse(p)
+		if !ok {
+			return 0
+		}
+		positions = append(positions, position)
+	}
+	return channelPositionsToMask(positions)
+}
+
+func channelPositionFromPulse(p byte) (ChannelPosition, bool) {
+	for position, pp := range pulseChannelPositions {
+		if pp == p {
+			return position, true
+		}
+	}
+	return 0, false
+}
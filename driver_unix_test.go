@@ -0,0 +1,154 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !android && !darwin && !js && !windows && !nintendosdk && !playstation5
+
+package oto
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jfreymuth/pulse/proto"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+func TestReconnectState(t *testing.T) {
+	var r reconnectState
+	now := time.Now()
+
+	// The first loss is reported only once.
+	if !r.lose() {
+		t.Errorf("first lose(): got: false, want: true")
+	}
+	if r.lose() {
+		t.Errorf("second lose(): got: true, want: false")
+	}
+	if !r.canRetry(now) {
+		t.Errorf("canRetry() before any failure: got: false, want: true")
+	}
+
+	// The retry interval is doubled up to the maximum.
+	for _, want := range []time.Duration{
+		500 * time.Millisecond,
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	} {
+		r.fail(now)
+		if r.canRetry(now.Add(want - time.Millisecond)) {
+			t.Errorf("canRetry() before %v: got: true, want: false", want)
+		}
+		if !r.canRetry(now.Add(want)) {
+			t.Errorf("canRetry() at %v: got: false, want: true", want)
+		}
+		now = now.Add(want)
+	}
+
+	// After reconnecting, the next loss is reported again and retried immediately.
+	r.succeed()
+	if !r.lose() {
+		t.Errorf("lose() after succeed(): got: false, want: true")
+	}
+	if !r.canRetry(now) {
+		t.Errorf("canRetry() after succeed(): got: false, want: true")
+	}
+	r.fail(now)
+	if r.canRetry(now.Add(deviceMonitorInterval - time.Millisecond)) {
+		t.Errorf("canRetry() after succeed() and fail(): got: true, want: false")
+	}
+}
+
+func TestPulseStreamChannels(t *testing.T) {
+	surround51, err := pulseChannelMap(6, channelMaskToPositions(ChannelMaskSurround51))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stereo, err := pulseChannelMap(2, channelMaskToPositions(ChannelMaskStereo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aux, err := pulseChannelMap(2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		channelMap   proto.ChannelMap
+		mask         mux.ChannelMask
+		sinkChannels proto.ChannelMap
+		wantMap      proto.ChannelMap
+		wantMask     mux.ChannelMask
+	}{
+		{
+			name:       "unknown sink",
+			channelMap: surround51,
+			mask:       mux.ChannelMaskSurround51,
+			wantMap:    surround51,
+			wantMask:   mux.ChannelMaskSurround51,
+		},
+		{
+			name:         "downmix",
+			channelMap:   surround51,
+			mask:         mux.ChannelMaskSurround51,
+			sinkChannels: stereo,
+			wantMap:      stereo,
+			wantMask:     mux.ChannelMaskStereo,
+		},
+		{
+			name:         "same channels",
+			channelMap:   surround51,
+			mask:         mux.ChannelMaskSurround51,
+			sinkChannels: surround51,
+			wantMap:      surround51,
+			wantMask:     mux.ChannelMaskSurround51,
+		},
+		{
+			name:         "more sink channels",
+			channelMap:   stereo,
+			mask:         mux.ChannelMaskStereo,
+			sinkChannels: surround51,
+			wantMap:      stereo,
+			wantMask:     mux.ChannelMaskStereo,
+		},
+		{
+			name:         "sink without speaker positions",
+			channelMap:   surround51,
+			mask:         mux.ChannelMaskSurround51,
+			sinkChannels: aux,
+			wantMap:      surround51,
+			wantMask:     mux.ChannelMaskSurround51,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			channelMap, channelCount, mask := pulseStreamChannels(tc.channelMap, tc.mask, tc.sinkChannels)
+			if !slices.Equal(channelMap, tc.wantMap) {
+				t.Errorf("channel map: got: %v, want: %v", channelMap, tc.wantMap)
+			}
+			if got, want := channelCount, len(tc.wantMap); got != want {
+				t.Errorf("channel count: got: %d, want: %d", got, want)
+			}
+			if mask != tc.wantMask {
+				t.Errorf("channel mask: got: %v, want: %v", mask, tc.wantMask)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"unsafe"
@@ -71,6 +72,11 @@ func (c *comThread) Run(f func()) {
 	<-ch
 }
 
+// Close ends the thread. Run must not be called after Close.
+func (c *comThread) Close() {
+	close(c.funcCh)
+}
+
 type wasapiContext struct {
 	sampleRate        int
 	channelCount      int
@@ -80,8 +86,12 @@ type wasapiContext struct {
 	comThread     *comThread
 	err           atomicError
 	suspended     bool
+	closed        bool
 	suspendedCond *sync.Cond
 
+	// loopWG waits for the loop goroutines including the ones restarting the loop.
+	loopWG sync.WaitGroup
+
 	sampleReadyEvent windows.Handle
 	client           *_IAudioClient2
 	bufferFrames     uint32
@@ -92,6 +102,9 @@ type wasapiContext struct {
 
 	buf []float32
 
+	// latency is the stream's latency and the buffer's duration, updated whenever the stream starts.
+	latency atomic.Int64
+
 	m sync.Mutex
 }
 
@@ -178,8 +191,14 @@ func (c *wasapiContext) start() error {
 		return cerr
 	}
 
+	c.loopWG.Add(1)
 	go func() {
+		defer c.loopWG.Done()
 		if err := c.loop(); err != nil {
+			if c.isClosed() {
+				return
+			}
+
 			// E_OUTOFMEMORY from IAudioRenderClient::GetBuffer has been observed on Xbox.
 			// The cause is not confirmed, but it appears to be rare and recoverable, so
 			// try restarting the client. The counter is reset after any successful buffer
@@ -267,13 +286,8 @@ func (c *wasapiContext) startOnCOMThread() (ferr error) {
 	// Fallback to WinMM in this case anyway.
 	const bitsPerSample = 32
 	nBlockAlign := c.channelCount * bitsPerSample / 8
-	var channelMask uint32
-	switch c.channelCount {
-	case 1:
-		channelMask = _SPEAKER_FRONT_CENTER
-	case 2:
-		channelMask = _SPEAKER_FRONT_LEFT | _SPEAKER_FRONT_RIGHT
-	}
+	// The mux's channel mask has the same bits as dwChannelMask.
+	channelMask := uint32(c.mux.ChannelMask())
 	f := &_WAVEFORMATEXTENSIBLE{
 		wFormatTag:      _WAVE_FORMAT_EXTENSIBLE,
 		nChannels:       uint16(c.channelCount),
@@ -314,6 +328,13 @@ func (c *wasapiContext) startOnCOMThread() (ferr error) {
 	}
 	c.bufferFrames = frames
 
+	streamLatency, err := c.client.GetStreamLatency()
+	if err != nil {
+		return err
+	}
+	// _REFERENCE_TIME is in 100 nanoseconds.
+	c.latency.Store(int64(time.Duration(streamLatency)*100 + time.Duration(int64(frames)*int64(time.Second)/int64(c.sampleRate))))
+
 	if c.renderClient != nil {
 		c.renderClient.Release()
 		c.renderClient = nil
@@ -361,11 +382,9 @@ func (c *wasapiContext) loop() error {
 func (c *wasapiContext) loopOnRenderThread() error {
 	last := time.Now()
 	for {
-		c.suspendedCond.L.Lock()
-		for c.suspended {
-			c.suspendedCond.Wait()
+		if !c.waitUntilResumed() {
+			return nil
 		}
-		c.suspendedCond.L.Unlock()
 
 		evt, err := windows.WaitForSingleObject(c.sampleReadyEvent, windows.INFINITE)
 		if err != nil {
@@ -374,6 +393,10 @@ func (c *wasapiContext) loopOnRenderThread() error {
 		if evt != windows.WAIT_OBJECT_0 {
 			return fmt.Errorf("oto: WaitForSingleObject failed: returned value: %d", evt)
 		}
+		// Close sets the event to end the loop.
+		if c.isClosed() {
+			return nil
+		}
 
 		if err := c.writeOnRenderThread(); err != nil {
 			return err
@@ -455,26 +478,81 @@ func (c *wasapiContext) Resume() error {
 	return nil
 }
 
+// waitUntilResumed waits until the context is resumed or closed.
+// waitUntilResumed returns false if the context is closed.
+func (c *wasapiContext) waitUntilResumed() bool {
+	c.suspendedCond.L.Lock()
+	defer c.suspendedCond.L.Unlock()
+	for c.suspended && !c.closed {
+		c.suspendedCond.Wait()
+	}
+	return !c.closed
+}
+
+func (c *wasapiContext) isClosed() bool {
+	c.suspendedCond.L.Lock()
+	defer c.suspendedCond.L.Unlock()
+	return c.closed
+}
+
 func (c *wasapiContext) isSuspended() bool {
 	c.suspendedCond.L.Lock()
 	defer c.suspendedCond.L.Unlock()
 	return c.suspended
 }
 
+// Latency returns the stream's latency and the duration of the buffer.
+func (c *wasapiContext) Latency() time.Duration {
+	return time.Duration(c.latency.Load())
+}
+
 func (c *wasapiContext) Err() error {
 	return c.err.Load()
 }
 
+// Close ends the loop, and releases the audio client.
+func (c *wasapiContext) Close() error {
+	c.suspendedCond.L.Lock()
+	c.closed = true
+	c.suspendedCond.L.Unlock()
+	c.suspendedCond.Signal()
+
+	// Wake the loop waiting for the event.
+	if err := windows.SetEvent(c.sampleReadyEvent); err != nil {
+		return err
+	}
+	c.loopWG.Wait()
+
+	c.comThread.Run(func() {
+		if c.client != nil {
+			_, _ = c.client.Stop()
+		}
+		if c.renderClient != nil {
+			c.renderClient.Release()
+			c.renderClient = nil
+		}
+		if c.client != nil {
+			c.client.Release()
+			c.client = nil
+		}
+		if c.enumerator != nil {
+			c.enumerator.Release()
+			c.enumerator = nil
+		}
+	})
+	c.comThread.Close()
+
+	return windows.CloseHandle(c.sampleReadyEvent)
+}
+
 func (c *wasapiContext) restart() error {
 	// Probably the driver is missing temporarily e.g. plugging out the headset.
 	// Recreate the device.
 
 retry:
-	c.suspendedCond.L.Lock()
-	for c.suspended {
-		c.suspendedCond.Wait()
+	if !c.waitUntilResumed() {
+		return nil
 	}
-	c.suspendedCond.L.Unlock()
 
 	if err := c.start(); err != nil {
 		// When a device is switched, the new device might not support the desired format,
@@ -17,6 +17,7 @@ package oto
 import (
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/ebitengine/oto/v3/internal/mux"
@@ -38,11 +39,14 @@ type context struct {
 	err   atomicError
 }
 
-func newContext(sampleRate int, channelCount int, format mux.Format, bufferSizeInBytes int, _ string) (*context, chan struct{}, error) {
+func newContext(m *mux.Mux, config *DriverConfig) (*context, chan struct{}, error) {
+	sampleRate, channelCount := config.SampleRate, config.ChannelCount
+	bufferSizeInBytes := config.bufferSizeInBytes()
+
 	ctx := &context{
 		sampleRate:   sampleRate,
 		channelCount: channelCount,
-		mux:          mux.New(sampleRate, channelCount, format),
+		mux:          m,
 		ready:        make(chan struct{}),
 	}
 
@@ -124,8 +128,41 @@ func (c *context) Err() error {
 	return nil
 }
 
+// Latency returns the latency of the underlying driver.
+// Latency returns 0 while the driver is being initialized, or if no device is found.
+func (c *context) Latency() time.Duration {
+	select {
+	case <-c.ready:
+	default:
+		return 0
+	}
+
+	if c.wasapiContext != nil {
+		return c.wasapiContext.Latency()
+	}
+	if c.winmmContext != nil {
+		return c.winmmContext.Latency()
+	}
+	return 0
+}
+
+func (c *context) Close() error {
+	<-c.ready
+	if c.wasapiContext != nil {
+		return c.wasapiContext.Close()
+	}
+	if c.winmmContext != nil {
+		return c.winmmContext.Close()
+	}
+	if c.nullContext != nil {
+		return c.nullContext.Close()
+	}
+	return nil
+}
+
 type nullContext struct {
 	suspended bool
+	closed    atomic.Bool
 }
 
 func newNullContext(sampleRate int, channelCount int, mux *mux.Mux) *nullContext {
@@ -137,7 +174,7 @@ func newNullContext(sampleRate int, channelCount int, mux *mux.Mux) *nullContext
 func (c *nullContext) loop(sampleRate int, channelCount int, mux *mux.Mux) {
 	var buf32 [4096]float32
 	sleep := time.Duration(float64(time.Second) * float64(len(buf32)) / float64(channelCount) / float64(sampleRate))
-	for {
+	for !c.closed.Load() {
 		if c.suspended {
 			time.Sleep(time.Second)
 			continue
@@ -161,3 +198,8 @@ func (c *nullContext) Resume() error {
 func (*nullContext) Err() error {
 	return nil
 }
+
+func (c *nullContext) Close() error {
+	c.closed.Store(true)
+	return nil
+}
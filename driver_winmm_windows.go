@@ -31,6 +31,9 @@ import (
 
 const defaultHeaderBufferSize = 4096
 
+// headerCount is the number of the headers queued to the device.
+const headerCount = 6
+
 type header struct {
 	waveOut uintptr
 	buffer  []float32
@@ -81,6 +84,7 @@ type winmmContext struct {
 	mux       *mux.Mux
 	err       atomicError
 	loopEndCh chan error
+	loopEnded bool
 
 	cond *sync.Cond
 
@@ -88,7 +92,26 @@ type winmmContext struct {
 	suspendedCond *sync.Cond
 }
 
-var theWinMMContext *winmmContext
+// theWinMMContexts is the contexts by their waveOut handles for the callback.
+var (
+	theWinMMContexts  = map[uintptr]*winmmContext{}
+	theWinMMContextsM sync.Mutex
+)
+
+// setWaveOut sets the waveOut handle, and registers the context for the callback.
+// If w is 0, the context is unregistered.
+func (c *winmmContext) setWaveOut(w uintptr) {
+	theWinMMContextsM.Lock()
+	defer theWinMMContextsM.Unlock()
+
+	if c.waveOut != 0 {
+		delete(theWinMMContexts, c.waveOut)
+	}
+	c.waveOut = w
+	if w != 0 {
+		theWinMMContexts[w] = c
+	}
+}
 
 func newWinMMContext(sampleRate, channelCount int, mux *mux.Mux, bufferSizeInBytes int) (*winmmContext, error) {
 	// winmm.dll is not available on Xbox.
@@ -104,7 +127,6 @@ func newWinMMContext(sampleRate, channelCount int, mux *mux.Mux, bufferSizeInByt
 		cond:              sync.NewCond(&sync.Mutex{}),
 		suspendedCond:     sync.NewCond(&sync.Mutex{}),
 	}
-	theWinMMContext = c
 
 	if err := c.start(); err != nil {
 		return nil, err
@@ -143,8 +165,8 @@ func (c *winmmContext) start() error {
 		headerBufferSize = c.bufferSizeInBytes
 	}
 
-	c.waveOut = w
-	c.headers = make([]*header, 0, 6)
+	c.setWaveOut(w)
+	c.headers = make([]*header, 0, headerCount)
 	for len(c.headers) < cap(c.headers) {
 		h, err := newHeader(c.waveOut, headerBufferSize)
 		if err != nil {
@@ -177,6 +199,16 @@ func (c *winmmContext) Resume() (ferr error) {
 	return nil
 }
 
+// Latency returns the duration of the queued headers.
+func (c *winmmContext) Latency() time.Duration {
+	headerBufferSize := defaultHeaderBufferSize
+	if c.bufferSizeInBytes != 0 {
+		headerBufferSize = c.bufferSizeInBytes
+	}
+	bytesPerSecond := int64(c.sampleRate) * int64(c.channelCount) * 4
+	return time.Duration(int64(headerCount*headerBufferSize) * int64(time.Second) / bytesPerSecond)
+}
+
 func (c *winmmContext) Err() error {
 	if err := c.err.Load(); err != nil {
 		return err.(error)
@@ -184,6 +216,27 @@ func (c *winmmContext) Err() error {
 	return nil
 }
 
+// Close ends the loop, and closes the device.
+func (c *winmmContext) Close() error {
+	c.cond.L.Lock()
+	if c.loopEnded {
+		c.cond.L.Unlock()
+		return nil
+	}
+	ch := make(chan error)
+	c.loopEndCh = ch
+	c.cond.L.Unlock()
+	c.cond.Signal()
+
+	// The loop might wait for resuming.
+	c.suspendedCond.L.Lock()
+	c.suspended = false
+	c.suspendedCond.L.Unlock()
+	c.suspendedCond.Signal()
+
+	return <-ch
+}
+
 func (c *winmmContext) isHeaderAvailable() bool {
 	for _, h := range c.headers {
 		if !h.IsQueued() {
@@ -200,7 +253,13 @@ var waveOutOpenCallback = windows.NewCallback(func(hwo, uMsg, dwInstance, dwPara
 	if uMsg != womDone {
 		return 0
 	}
-	theWinMMContext.cond.Signal()
+	theWinMMContextsM.Lock()
+	c, ok := theWinMMContexts[hwo]
+	theWinMMContextsM.Unlock()
+	if !ok {
+		return 0
+	}
+	c.cond.Signal()
 	return 0
 })
 
@@ -238,6 +297,7 @@ func (c *winmmContext) closeLoop() (ferr error) {
 	c.cond.L.Lock()
 	defer c.cond.L.Unlock()
 
+	c.loopEnded = true
 	defer func() {
 		if c.loopEndCh != nil {
 			if ferr != nil {
@@ -249,6 +309,10 @@ func (c *winmmContext) closeLoop() (ferr error) {
 		}
 	}()
 
+	// Return the queued headers to unprepare them.
+	if err := waveOutReset(c.waveOut); err != nil {
+		return err
+	}
 	for _, h := range c.headers {
 		if err := h.Close(); err != nil {
 			return err
@@ -259,7 +323,7 @@ func (c *winmmContext) closeLoop() (ferr error) {
 	if err := waveOutClose(c.waveOut); err != nil {
 		return err
 	}
-	c.waveOut = 0
+	c.setWaveOut(0)
 	return nil
 }
 
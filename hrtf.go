@@ -0,0 +1,82 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"io"
+	"sync"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// HRTF is a set of head-related impulse responses for binaural rendering.
+//
+// An HRTF is immutable. All the functions of an HRTF are concurrent-safe.
+type HRTF struct {
+	hrtf *mux.HRTF
+}
+
+// LoadHRTF loads an HRTF from a JSON file with fields named after SOFA's SimpleFreeFieldHRIR convention:
+//
+//	{
+//	  "Data.SamplingRate": 48000,
+//	  "SourcePosition": [[azimuth, elevation, distance], ...],
+//	  "Data.IR": [[[left...], [right...]], ...]
+//	}
+//
+// Azimuths and elevations are in degrees. An azimuth is counterclockwise from the front, i.e. 90 is the left.
+// Distances are ignored. The impulse responses are resampled to the context's sample rate if they differ.
+//
+// A SOFA file can be converted to this format with a SOFA reader, e.g. by dumping the variables of the same names.
+func LoadHRTF(r io.Reader) (*HRTF, error) {
+	h, err := mux.LoadHRTF(r)
+	if err != nil {
+		return nil, err
+	}
+	return &HRTF{
+		hrtf: h,
+	}, nil
+}
+
+var defaultHRTF = sync.OnceValue(func() *HRTF {
+	return &HRTF{
+		hrtf: mux.DefaultHRTF(48000),
+	}
+})
+
+// DefaultHRTF returns the built-in HRTF generated from a spherical head and pinna model.
+//
+// The built-in HRTF is not as accurate as a measured one, but it gives interaural time and level differences
+// and spectral cues for front/back and elevation.
+func DefaultHRTF() *HRTF {
+	return defaultHRTF()
+}
+
+// SetHRTF enables binaural rendering with the HRTF for headphones.
+//
+// With an HRTF, a player with an emitter is convolved with the impulse responses for its direction
+// instead of being panned. The distance attenuation, the air absorption and the Doppler effect are applied as well.
+// Binaural rendering is available only when the context has two channels.
+//
+// If h is nil, binaural rendering is disabled.
+//
+// SetHRTF is concurrent-safe.
+func (c *Context) SetHRTF(h *HRTF) {
+	if h == nil {
+		c.mux.SetHRTF(nil)
+		return
+	}
+	c.mux.SetHRTF(h.hrtf)
+}
@@ -0,0 +1,216 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"sync"
+)
+
+// Analyzer keeps the latest samples of a player or the master output, and analyzes them on demand.
+//
+// The render path writes mono samples to Analyzer without waiting. The analysis runs on the caller's goroutine.
+type Analyzer struct {
+	size       int
+	sampleRate int
+
+	// ring is the latest samples. pos is the position to write the next sample.
+	// ring and pos are protected by m. The render path only tries to lock m, and skips writing if it fails.
+	ring []float32
+	pos  int
+	m    sync.Mutex
+
+	// The fields below are used for the analysis, and protected by analysisM.
+	window    []float64
+	samples   []float32
+	fft       []complex128
+	analysisM sync.Mutex
+}
+
+// NewAnalyzer creates a new Analyzer with the FFT size.
+// size must be a power of two and at least 32.
+func NewAnalyzer(size int, sampleRate int) (*Analyzer, error) {
+	if size < 32 || bits.OnesCount(uint(size)) != 1 {
+		return nil, fmt.Errorf("mux: the analyzer size must be a power of two and at least 32: %d", size)
+	}
+	a := &Analyzer{
+		size:       size,
+		sampleRate: sampleRate,
+		ring:       make([]float32, size),
+		window:     make([]float64, size),
+		samples:    make([]float32, size),
+		fft:        make([]complex128, size),
+	}
+	// The Hann window.
+	for i := range a.window {
+		a.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size))
+	}
+	return a, nil
+}
+
+// Size returns the FFT size.
+func (a *Analyzer) Size() int {
+	return a.size
+}
+
+// write writes the samples multiplied by the gain as mono.
+//
+// write is called on the render path, and never blocks. write does nothing if a is nil.
+func (a *Analyzer) write(samples []float32, gain float32, channelCount int) {
+	if a == nil {
+		return
+	}
+	if !a.m.TryLock() {
+		return
+	}
+	defer a.m.Unlock()
+
+	g := gain / float32(channelCount)
+	for i := 0; i+channelCount <= len(samples); i += channelCount {
+		var v float32
+		for _, s := range samples[i : i+channelCount] {
+			v += s
+		}
+		a.ring[a.pos] = v * g
+		a.pos = (a.pos + 1) & (a.size - 1)
+	}
+}
+
+// Waveform appends the latest samples from the oldest to dst, and returns the result.
+// The number of the samples is the FFT size.
+func (a *Analyzer) Waveform(dst []float32) []float32 {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	dst = append(dst, a.ring[a.pos:]...)
+	return append(dst, a.ring[:a.pos]...)
+}
+
+// analyze calculates the FFT of the windowed latest samples.
+//
+// When analyze is called, the mutex analysisM must be locked.
+func (a *Analyzer) analyze() {
+	a.samples = a.Waveform(a.samples[:0])
+	for i, v := range a.samples {
+		a.fft[i] = complex(float64(v)*a.window[i], 0)
+	}
+	fft(a.fft)
+}
+
+// magnitude returns the magnitude of the k-th bin.
+// The magnitude is normalized so that a sine wave of amplitude 1 at a bin's frequency has a magnitude of 1.
+//
+// When magnitude is called, the mutex analysisM must be locked.
+func (a *Analyzer) magnitude(k int) float64 {
+	// The sum of the Hann window is size/2. The positive and negative frequencies share the amplitude.
+	m := cmplx.Abs(a.fft[k]) / (float64(a.size) / 2)
+	if k != 0 && k != a.size/2 {
+		m *= 2
+	}
+	return m
+}
+
+// Spectrum appends the magnitudes of the frequency bins to dst, and returns the result.
+// The number of the bins is size/2+1. The frequency of the k-th bin is k * sampleRate / size.
+func (a *Analyzer) Spectrum(dst []float32) []float32 {
+	a.analysisM.Lock()
+	defer a.analysisM.Unlock()
+
+	a.analyze()
+	for k := range a.size/2 + 1 {
+		dst = append(dst, float32(a.magnitude(k)))
+	}
+	return dst
+}
+
+// LogSpectrum appends the magnitudes of the logarithmically spaced bands from minFreq to maxFreq to dst, and returns the result.
+// The magnitude of a band is the root mean square of the bins in the band.
+// If a band is narrower than a bin, the magnitude is interpolated at the band's center frequency.
+// minFreq must be positive.
+func (a *Analyzer) LogSpectrum(dst []float32, bands int, minFreq, maxFreq float64) []float32 {
+	a.analysisM.Lock()
+	defer a.analysisM.Unlock()
+
+	a.analyze()
+
+	binWidth := float64(a.sampleRate) / float64(a.size)
+	ratio := math.Pow(maxFreq/minFreq, 1/float64(bands))
+	for i := range bands {
+		f0 := minFreq * math.Pow(ratio, float64(i))
+		f1 := f0 * ratio
+		// Bins above the Nyquist frequency don't exist.
+		k0 := min(int(math.Ceil(f0/binWidth)), a.size/2+1)
+		k1 := min(int(math.Ceil(f1/binWidth)), a.size/2+1)
+		if k1 > k0 {
+			var sum float64
+			for k := k0; k < k1; k++ {
+				m := a.magnitude(k)
+				sum += m * m
+			}
+			dst = append(dst, float32(math.Sqrt(sum/float64(k1-k0))))
+			continue
+		}
+		// Interpolate the magnitude at the center.
+		pos := min(math.Sqrt(f0*f1)/binWidth, float64(a.size/2))
+		k := min(int(pos), a.size/2-1)
+		rate := pos - float64(k)
+		dst = append(dst, float32(a.magnitude(k)*(1-rate)+a.magnitude(k+1)*rate))
+	}
+	return dst
+}
+
+// fft calculates the discrete Fourier transform of x in place. The length of x must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range x {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := range size / 2 {
+				u := x[start+k]
+				v := x[start+k+size/2] * wk
+				x[start+k] = u + v
+				x[start+k+size/2] = u - v
+				wk *= w
+			}
+		}
+	}
+}
+
+// Analyzer returns the analyzer of the master output.
+func (m *Mux) Analyzer() *Analyzer {
+	return m.analyzer.Load()
+}
+
+// SetAnalyzer attaches the analyzer to the master output. If a is nil, the analyzer is detached.
+func (m *Mux) SetAnalyzer(a *Analyzer) {
+	m.analyzer.Store(a)
+}
+
+// SetAnalyzer attaches the analyzer to the player. If a is nil, the analyzer is detached.
+// The analyzer receives the player's samples after its volume.
+func (p *Player) SetAnalyzer(a *Analyzer) {
+	p.p.analyzer.Store(a)
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"sync/atomic"
+)
+
+// Bus is a group of players with its own volume.
+// The players of a bus are mixed into the bus's buffer, which is mixed into the output with the bus's volume.
+//
+// A bus lives as long as its mux.
+type Bus struct {
+	mux *Mux
+
+	volume atomicFloat64
+	muted  atomic.Bool
+
+	// meter is the meter of the bus's mixed samples after the volume.
+	meter meter
+
+	// The fields below are used only on the render path.
+	//
+	// prevVolume is the effective volume applied to the last buffer, and curVolume is the one for the current buffer.
+	// inaudible reports whether the bus is silent for the current buffer.
+	// buf is the mixed samples of the bus's players for the current buffer.
+	prevVolume float64
+	curVolume  float64
+	inaudible  bool
+	buf        []float32
+}
+
+// NewBus creates a new bus with volume 1.
+func (m *Mux) NewBus() *Bus {
+	b := &Bus{
+		mux:       m,
+		curVolume: 1,
+	}
+	b.volume.Store(1)
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	buses := make([]*Bus, 0, len(*m.buses.Load())+1)
+	buses = append(buses, *m.buses.Load()...)
+	buses = append(buses, b)
+	m.buses.Store(&buses)
+	return b
+}
+
+// Volume returns the volume of the bus.
+func (b *Bus) Volume() float64 {
+	return b.volume.Load()
+}
+
+// SetVolume sets the volume of the bus. The volume is clamped to [0, MaxVolume].
+func (b *Bus) SetVolume(volume float64) {
+	b.volume.Store(clampVolume(volume))
+}
+
+// IsMuted reports whether the bus is muted.
+func (b *Bus) IsMuted() bool {
+	return b.muted.Load()
+}
+
+// SetMuted mutes or unmutes the bus. The volume is kept while the bus is muted.
+func (b *Bus) SetMuted(muted bool) {
+	b.muted.Store(muted)
+}
+
+// begin prepares the bus for a buffer of n samples.
+// If the output is inaudible, the bus is also inaudible and its players are virtual voices.
+//
+// begin is called only on the render path.
+func (b *Bus) begin(n int, inaudible bool) {
+	b.prevVolume = b.curVolume
+	b.curVolume = b.volume.Load()
+	if b.muted.Load() {
+		b.curVolume = 0
+	}
+	b.inaudible = inaudible || (b.prevVolume == 0 && b.curVolume == 0)
+
+	// The buffer grows only when the driver passes a larger buffer than before.
+	// The buffer's length is needed even for virtual voices to advance their positions.
+	if cap(b.buf) < n {
+		b.buf = make([]float32, n)
+	}
+	b.buf = b.buf[:n]
+	if !b.inaudible {
+		clear(b.buf)
+	}
+}
+
+// end adds the mixed samples of the bus to dst with the bus's volume.
+//
+// end is called only on the render path.
+func (b *Bus) end(dst []float32) {
+	var mb meterBlock
+	defer b.meter.update(&mb, len(b.buf), b.mux.meterCoef(len(b.buf)))
+
+	if b.inaudible {
+		return
+	}
+	addWithVolume(dst, b.buf, float32(b.prevVolume), float32(b.curVolume), b.mux.channelCount)
+	mb.add(b.buf, float32(b.curVolume))
+}
+
+// Meter returns the meter of the bus.
+func (b *Bus) Meter() Meter {
+	return b.meter.Load()
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"math"
+	"math/bits"
+)
+
+// ChannelMask is a set of speaker positions.
+// The channels of a frame are ordered by the bits of the positions from the lowest.
+//
+// ChannelMask must sync with oto's ChannelMask. The bits are the same as WAVEFORMATEXTENSIBLE's dwChannelMask.
+type ChannelMask uint32
+
+const (
+	ChannelFrontLeft ChannelMask = 1 << iota
+	ChannelFrontRight
+	ChannelFrontCenter
+	ChannelLowFrequency
+	ChannelBackLeft
+	ChannelBackRight
+	ChannelFrontLeftOfCenter
+	ChannelFrontRightOfCenter
+	ChannelBackCenter
+	ChannelSideLeft
+	ChannelSideRight
+)
+
+const (
+	ChannelMaskMono       = ChannelFrontCenter
+	ChannelMaskStereo     = ChannelFrontLeft | ChannelFrontRight
+	ChannelMaskQuad       = ChannelFrontLeft | ChannelFrontRight | ChannelBackLeft | ChannelBackRight
+	ChannelMaskSurround51 = ChannelFrontLeft | ChannelFrontRight | ChannelFrontCenter | ChannelLowFrequency | ChannelBackLeft | ChannelBackRight
+	ChannelMaskSurround71 = ChannelMaskSurround51 | ChannelSideLeft | ChannelSideRight
+)
+
+// DefaultChannelMask returns the usual speaker positions for the channel count.
+// DefaultChannelMask returns 0 if there are no usual positions.
+func DefaultChannelMask(channelCount int) ChannelMask {
+	switch channelCount {
+	case 1:
+		return ChannelMaskMono
+	case 2:
+		return ChannelMaskStereo
+	case 4:
+		return ChannelMaskQuad
+	case 6:
+		return ChannelMaskSurround51
+	case 8:
+		return ChannelMaskSurround71
+	}
+	return 0
+}
+
+// Channels returns the speaker positions in the order of the channels.
+func (c ChannelMask) Channels() []ChannelMask {
+	var cs []ChannelMask
+	for c != 0 {
+		b := c & -c
+		cs = append(cs, b)
+		c &^= b
+	}
+	return cs
+}
+
+// Count returns the number of the speaker positions.
+func (c ChannelMask) Count() int {
+	return bits.OnesCount32(uint32(c))
+}
+
+type channelGain struct {
+	channel ChannelMask
+	gain    float32
+}
+
+// channelFallbacks is the alternatives of a speaker position that a destination doesn't have.
+// The first alternative that the destination can reproduce is used.
+// A low frequency channel without a destination is dropped.
+var channelFallbacks = map[ChannelMask][][]channelGain{
+	ChannelFrontLeft:          {{{ChannelFrontCenter, math.Sqrt2 / 2}}},
+	ChannelFrontRight:         {{{ChannelFrontCenter, math.Sqrt2 / 2}}},
+	ChannelFrontCenter:        {{{ChannelFrontLeft, math.Sqrt2 / 2}, {ChannelFrontRight, math.Sqrt2 / 2}}},
+	ChannelBackLeft:           {{{ChannelSideLeft, 1}}, {{ChannelFrontLeft, math.Sqrt2 / 2}}},
+	ChannelBackRight:          {{{ChannelSideRight, 1}}, {{ChannelFrontRight, math.Sqrt2 / 2}}},
+	ChannelFrontLeftOfCenter:  {{{ChannelFrontLeft, 1}}},
+	ChannelFrontRightOfCenter: {{{ChannelFrontRight, 1}}},
+	ChannelBackCenter: {
+		{{ChannelBackLeft, math.Sqrt2 / 2}, {ChannelBackRight, math.Sqrt2 / 2}},
+		{{ChannelSideLeft, math.Sqrt2 / 2}, {ChannelSideRight, math.Sqrt2 / 2}},
+		{{ChannelFrontLeft, 0.5}, {ChannelFrontRight, 0.5}},
+	},
+	ChannelSideLeft:  {{{ChannelBackLeft, 1}}, {{ChannelFrontLeft, math.Sqrt2 / 2}}},
+	ChannelSideRight: {{{ChannelBackRight, 1}}, {{ChannelFrontRight, math.Sqrt2 / 2}}},
+}
+
+// resolveChannel returns the destination channels and their gains to reproduce the speaker position c.
+// visited is the positions already tried, to avoid cycles.
+func resolveChannel(c ChannelMask, dst ChannelMask, visited ChannelMask) []channelGain {
+	if dst&c != 0 {
+		return []channelGain{{c, 1}}
+	}
+	visited |= c
+
+alternatives:
+	for _, alt := range channelFallbacks[c] {
+		var r []channelGain
+		for _, cg := range alt {
+			if visited&cg.channel != 0 {
+				continue alternatives
+			}
+			gs := resolveChannel(cg.channel, dst, visited)
+			if len(gs) == 0 {
+				continue alternatives
+			}
+			for _, g := range gs {
+				r = append(r, channelGain{g.channel, g.gain * cg.gain})
+			}
+		}
+		return r
+	}
+	return nil
+}
+
+// ChannelMatrix returns a mixing matrix from srcChannelCount channels at srcMask to dstChannelCount channels at dstMask.
+// The matrix has dstChannelCount rows and srcChannelCount columns, and the element at (i, j) is matrix[i*srcChannelCount+j].
+//
+// Channels without speaker positions, e.g. channels beyond the count of a mask, are mapped to the channels at the same index.
+func ChannelMatrix(srcChannelCount int, srcMask ChannelMask, dstChannelCount int, dstMask ChannelMask) []float32 {
+	matrix := make([]float32, dstChannelCount*srcChannelCount)
+
+	srcChannels := srcMask.Channels()
+	dstChannels := dstMask.Channels()
+	dstIndex := map[ChannelMask]int{}
+	for i, c := range dstChannels {
+		if i < dstChannelCount {
+			dstIndex[c] = i
+		}
+	}
+	var dstKnown ChannelMask
+	for c := range dstIndex {
+		dstKnown |= c
+	}
+
+	for j := range srcChannelCount {
+		if j >= len(srcChannels) || dstKnown == 0 {
+			if j < dstChannelCount {
+				matrix[j*srcChannelCount+j] = 1
+			}
+			continue
+		}
+		for _, g := range resolveChannel(srcChannels[j], dstKnown, 0) {
+			matrix[dstIndex[g.channel]*srcChannelCount+j] += g.gain
+		}
+	}
+	return matrix
+}
+
+// appendMatrixConverted converts one frame by the matrix and appends it to dst.
+func appendMatrixConverted(dst []float32, frame []float32, matrix []float32, dstChannelCount int) []float32 {
+	srcChannelCount := len(frame)
+	for i := range dstChannelCount {
+		var v float32
+		for j, s := range frame {
+			v += s * matrix[i*srcChannelCount+j]
+		}
+		dst = append(dst, v)
+	}
+	return dst
+}
+
+// applyMatrix converts src by the matrix and writes the result to dst. dst must have enough length.
+func applyMatrix(dst []float32, src []float32, matrix []float32, srcChannelCount, dstChannelCount int) {
+	frames := len(src) / srcChannelCount
+	for f := range frames {
+		frame := src[f*srcChannelCount : (f+1)*srcChannelCount]
+		out := dst[f*dstChannelCount : (f+1)*dstChannelCount]
+		for i := range out {
+			var v float32
+			for j, s := range frame {
+				v += s * matrix[i*srcChannelCount+j]
+			}
+			out[i] = v
+		}
+	}
+}
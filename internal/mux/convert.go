@@ -0,0 +1,226 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"fmt"
+	"math"
+)
+
+// appendDecoded decodes src in the format and appends the float32 values to dst.
+func (f Format) appendDecoded(dst []float32, src []byte) []float32 {
+	switch f {
+	case FormatFloat32LE:
+		for i := 0; i < len(src); i += 4 {
+			dst = append(dst, math.Float32frombits(uint32(src[i])|uint32(src[i+1])<<8|uint32(src[i+2])<<16|uint32(src[i+3])<<24))
+		}
+	case FormatUnsignedInt8:
+		for _, v8 := range src {
+			dst = append(dst, float32(v8-(1<<7))/(1<<7))
+		}
+	case FormatSignedInt16LE:
+		for i := 0; i < len(src); i += 2 {
+			v16 := int16(src[i]) | (int16(src[i+1]) << 8)
+			dst = append(dst, float32(v16)/(1<<15))
+		}
+	default:
+		panic(fmt.Sprintf("mux: unexpected format: %d", f))
+	}
+	return dst
+}
+
+// AppendEncoded encodes the float32 values in src in the format and appends the bytes to dst.
+// The values out of [-1, 1] are clamped for the integer formats.
+func (f Format) AppendEncoded(dst []byte, src []float32) []byte {
+	switch f {
+	case FormatFloat32LE:
+		for _, v := range src {
+			b := math.Float32bits(v)
+			dst = append(dst, byte(b), byte(b>>8), byte(b>>16), byte(b>>24))
+		}
+	case FormatUnsignedInt8:
+		for _, v := range src {
+			v8 := min(max(int(math.Round(float64(v)*(1<<7))), -(1<<7)), (1<<7)-1)
+			dst = append(dst, byte(v8+(1<<7)))
+		}
+	case FormatSignedInt16LE:
+		for _, v := range src {
+			v16 := min(max(int(math.Round(float64(v)*(1<<15))), -(1<<15)), (1<<15)-1)
+			dst = append(dst, byte(v16), byte(v16>>8))
+		}
+	default:
+		panic(fmt.Sprintf("mux: unexpected format: %d", f))
+	}
+	return dst
+}
+
+// converter converts a source's samples to the mux's channel count and sample rate.
+//
+// The sample rate is converted by linear interpolation.
+type converter struct {
+	srcChannelCount int
+	dstChannelCount int
+	srcSampleRate   int
+	dstSampleRate   int
+
+	// matrix is the channel mixing matrix. If matrix is nil, channels are converted in the simple way for mono and stereo.
+	matrix []float32
+
+	// prev is the last frame of the previous input, in the destination channel layout.
+	prev    []float32
+	hasPrev bool
+
+	// pos is the position of the next output frame.
+	// 0 means prev, and 1 means the first frame of the next input.
+	pos float64
+
+	channelConverted []float32
+}
+
+// newConverter creates a converter from a source with the options to the mux's layout.
+func (m *Mux) newConverter(options *PlayerOptions) converter {
+	c := converter{
+		srcChannelCount: options.ChannelCount,
+		dstChannelCount: m.channelCount,
+		srcSampleRate:   options.SampleRate,
+		dstSampleRate:   m.sampleRate,
+	}
+
+	switch {
+	case options.ChannelMatrix != nil:
+		c.matrix = options.ChannelMatrix
+	case options.ChannelMask != 0 || options.ChannelCount > 2 || m.channelCount > 2:
+		// Mix the channels by the speaker positions.
+		srcMask := options.ChannelMask
+		if srcMask == 0 {
+			srcMask = DefaultChannelMask(options.ChannelCount)
+		}
+		if options.ChannelCount != m.channelCount || srcMask != m.channelMask {
+			c.matrix = ChannelMatrix(options.ChannelCount, srcMask, m.channelCount, m.channelMask)
+		}
+	}
+	return c
+}
+
+func (c *converter) isIdentity() bool {
+	return c.srcChannelCount == c.dstChannelCount && c.srcSampleRate == c.dstSampleRate && c.matrix == nil
+}
+
+// reset forgets the previous input e.g. on seeking.
+func (c *converter) reset() {
+	c.hasPrev = false
+	c.pos = 0
+}
+
+// sourceFrames returns the number of source frames that are converted to at most dstFrames frames.
+func (c *converter) sourceFrames(dstFrames int) int {
+	if c.srcSampleRate == c.dstSampleRate {
+		return dstFrames
+	}
+	// One more frame can be output due to the fractional position.
+	return max(int(float64(dstFrames-1)*float64(c.srcSampleRate)/float64(c.dstSampleRate)), 0)
+}
+
+// minDstFrames returns the smallest number of frames for which sourceFrames returns a positive number.
+func (c *converter) minDstFrames() int {
+	if c.srcSampleRate == c.dstSampleRate {
+		return 1
+	}
+	return 1 + (c.dstSampleRate+c.srcSampleRate-1)/c.srcSampleRate
+}
+
+// appendConverted converts src, which must consist of whole frames, and appends the result to dst.
+func (c *converter) appendConverted(dst []float32, src []float32) []float32 {
+	frames := len(src) / c.srcChannelCount
+
+	converted := src
+	if c.matrix != nil {
+		c.channelConverted = c.channelConverted[:0]
+		for i := range frames {
+			c.channelConverted = appendMatrixConverted(c.channelConverted, src[i*c.srcChannelCount:(i+1)*c.srcChannelCount], c.matrix, c.dstChannelCount)
+		}
+		converted = c.channelConverted
+	} else if c.srcChannelCount != c.dstChannelCount {
+		c.channelConverted = c.channelConverted[:0]
+		for i := range frames {
+			c.channelConverted = appendChannelConverted(c.channelConverted, src[i*c.srcChannelCount:(i+1)*c.srcChannelCount], c.dstChannelCount)
+		}
+		converted = c.channelConverted
+	}
+
+	if c.srcSampleRate == c.dstSampleRate {
+		return append(dst, converted...)
+	}
+	if frames == 0 {
+		return dst
+	}
+
+	ch := c.dstChannelCount
+	if !c.hasPrev {
+		c.prev = append(c.prev[:0], converted[:ch]...)
+		c.hasPrev = true
+		c.pos = 1
+	}
+
+	// frame returns the i-th frame where 0 is prev and i (> 0) is the (i-1)-th frame of converted.
+	frame := func(i int) []float32 {
+		if i == 0 {
+			return c.prev
+		}
+		return converted[(i-1)*ch : i*ch]
+	}
+
+	step := float64(c.srcSampleRate) / float64(c.dstSampleRate)
+	for c.pos < float64(frames) {
+		idx := int(c.pos)
+		rate := float32(c.pos - float64(idx))
+		f0, f1 := frame(idx), frame(idx+1)
+		for j := range ch {
+			dst = append(dst, f0[j]+(f1[j]-f0[j])*rate)
+		}
+		c.pos += step
+	}
+	c.pos -= float64(frames)
+	c.prev = append(c.prev[:0], converted[(frames-1)*ch:frames*ch]...)
+	return dst
+}
+
+// appendChannelConverted converts one frame to dstChannelCount channels and appends it to dst.
+func appendChannelConverted(dst []float32, frame []float32, dstChannelCount int) []float32 {
+	switch {
+	case len(frame) == dstChannelCount:
+		return append(dst, frame...)
+	case len(frame) == 1:
+		for range dstChannelCount {
+			dst = append(dst, frame[0])
+		}
+		return dst
+	case dstChannelCount == 1:
+		var v float32
+		for _, s := range frame {
+			v += s
+		}
+		return append(dst, v/float32(len(frame)))
+	default:
+		for i := range dstChannelCount {
+			if i < len(frame) {
+				dst = append(dst, frame[i])
+			} else {
+				dst = append(dst, 0)
+			}
+		}
+		return dst
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// HRIR is a pair of head-related impulse responses measured for a direction.
+type HRIR struct {
+	// Azimuth is the counterclockwise angle in degrees from the front, i.e. a positive value is to the left.
+	Azimuth float64
+
+	// Elevation is the angle in degrees from the horizontal plane. A positive value is upward.
+	Elevation float64
+
+	Left  []float32
+	Right []float32
+}
+
+// HRTF is a set of head-related impulse responses.
+type HRTF struct {
+	SampleRate int
+	HRIRs      []HRIR
+}
+
+// sofaJSON is the structure of an HRTF file.
+// The names follow SOFA's SimpleFreeFieldHRIR convention.
+type sofaJSON struct {
+	SamplingRate   float64       `json:"Data.SamplingRate"`
+	IR             [][][]float32 `json:"Data.IR"`
+	SourcePosition [][]float64   `json:"SourcePosition"`
+}
+
+// LoadHRTF loads an HRTF from a JSON file with SOFA-like fields:
+//
+//	{
+//	  "Data.SamplingRate": 48000,
+//	  "SourcePosition": [[azimuth, elevation, distance], ...],
+//	  "Data.IR": [[[left...], [right...]], ...]
+//	}
+//
+// Azimuths and elevations are in degrees as SOFA's spherical coordinates. Distances are ignored.
+func LoadHRTF(r io.Reader) (*HRTF, error) {
+	var s sofaJSON
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("mux: decoding an HRTF failed: %w", err)
+	}
+	if s.SamplingRate <= 0 {
+		return nil, errors.New("mux: Data.SamplingRate must be positive")
+	}
+	if len(s.IR) == 0 {
+		return nil, errors.New("mux: Data.IR must not be empty")
+	}
+	if len(s.IR) != len(s.SourcePosition) {
+		return nil, fmt.Errorf("mux: the numbers of Data.IR and SourcePosition don't match: %d vs %d", len(s.IR), len(s.SourcePosition))
+	}
+
+	h := &HRTF{
+		SampleRate: int(s.SamplingRate),
+	}
+	for i, ir := range s.IR {
+		if len(ir) != 2 {
+			return nil, fmt.Errorf("mux: Data.IR[%d] must have 2 receivers but %d", i, len(ir))
+		}
+		if len(ir[0]) == 0 || len(ir[0]) != len(ir[1]) {
+			return nil, fmt.Errorf("mux: Data.IR[%d] must have the same non-zero lengths for the receivers", i)
+		}
+		pos := s.SourcePosition[i]
+		if len(pos) < 2 {
+			return nil, fmt.Errorf("mux: SourcePosition[%d] must have an azimuth and an elevation", i)
+		}
+		h.HRIRs = append(h.HRIRs, HRIR{
+			Azimuth:   pos[0],
+			Elevation: pos[1],
+			Left:      ir[0],
+			Right:     ir[1],
+		})
+	}
+	return h, nil
+}
+
+// DefaultHRTF generates an HRTF from a spherical head and pinna model at the sample rate.
+//
+// The model is based on C. P. Brown and R. O. Duda, "A Structural Model for Binaural Sound Synthesis" (1998):
+// a head shadow filter and an interaural time delay for each ear, and pinna reflections depending on the direction.
+func DefaultHRTF(sampleRate int) *HRTF {
+	const (
+		headRadius   = 0.0875
+		speedOfSound = 343.3
+		length       = 128
+	)
+
+	h := &HRTF{
+		SampleRate: sampleRate,
+	}
+	for elevation := -40; elevation <= 90; elevation += 10 {
+		step := 10
+		if elevation == 90 {
+			step = 360
+		}
+		for azimuth := 0; azimuth < 360; azimuth += step {
+			az := float64(azimuth) * math.Pi / 180
+			el := float64(elevation) * math.Pi / 180
+			// The direction in SOFA's coordinates: X is the front, Y is the left, and Z is the top.
+			dir := Vector{math.Cos(el) * math.Cos(az), math.Cos(el) * math.Sin(az), math.Sin(el)}
+
+			hrir := HRIR{
+				Azimuth:   float64(azimuth),
+				Elevation: float64(elevation),
+				Left:      make([]float32, length),
+				Right:     make([]float32, length),
+			}
+			for ear, ir := range [][]float32{hrir.Left, hrir.Right} {
+				earDir := Vector{0, 1, 0}
+				if ear == 1 {
+					earDir = Vector{0, -1, 0}
+				}
+				// theta is the angle between the source and the ear.
+				theta := math.Acos(min(max(dir.dot(earDir), -1), 1))
+
+				// Woodworth's formula for the time delay around a sphere.
+				var delay float64
+				if theta < math.Pi/2 {
+					delay = headRadius / speedOfSound * (1 - math.Cos(theta))
+				} else {
+					delay = headRadius / speedOfSound * (1 + theta - math.Pi/2)
+				}
+				// Add a constant delay to keep the pinna reflections causal.
+				delaySamples := delay*float64(sampleRate) + 2
+
+				// The pinna reflections.
+				pinnaRho := [...]float64{1, 0.5, -1, 0.5, -0.25, 0.25}
+				pinnaA := [...]float64{0, 1, 5, 5, 5, 5}
+				pinnaB := [...]float64{0, 2, 4, 7, 11, 13}
+				pinnaD := [...]float64{0, 1, 0.5, 0.5, 0.5, 0.5}
+				// The reflections are defined at 44100 [Hz].
+				scale := float64(sampleRate) / 44100
+				var impulse [length]float64
+				for k := range pinnaRho {
+					tau := delaySamples
+					if k > 0 {
+						tau += (pinnaA[k]*math.Cos(az/2)*math.Sin(pinnaD[k]*(math.Pi/2-el)) + pinnaB[k]) * scale
+					}
+					i := int(tau)
+					f := tau - float64(i)
+					if i+1 < length {
+						impulse[i] += pinnaRho[k] * (1 - f)
+						impulse[i+1] += pinnaRho[k] * f
+					}
+				}
+
+				// The head shadow filter: H(s) = (1 + alpha s / (2 w0)) / (1 + s / (2 w0)), discretized by the bilinear transform.
+				const alphaMin, thetaMin = 0.1, 150 * math.Pi / 180
+				alpha := (1 + alphaMin/2) + (1-alphaMin/2)*math.Cos(theta/thetaMin*math.Pi)
+				w0 := speedOfSound / headRadius
+				k := 2 * float64(sampleRate)
+				a0 := 1 + k/(2*w0)
+				b0 := (1 + alpha*k/(2*w0)) / a0
+				b1 := (1 - alpha*k/(2*w0)) / a0
+				a1 := (1 - k/(2*w0)) / a0
+				var x1, y1 float64
+				for i, x := range impulse {
+					y := b0*x + b1*x1 - a1*y1
+					x1, y1 = x, y
+					// Fade out the tail not to make discontinuities.
+					w := 1.0
+					if i >= length*3/4 {
+						w = float64(length-i) / float64(length/4)
+					}
+					ir[i] = float32(y * w)
+				}
+			}
+			h.HRIRs = append(h.HRIRs, hrir)
+		}
+	}
+	return h
+}
+
+// hrtfSet is an HRTF prepared for rendering.
+type hrtfSet struct {
+	length int
+
+	// directions is the unit vectors of the HRIRs in SOFA's coordinates.
+	directions []Vector
+	left       [][]float32
+	right      [][]float32
+}
+
+// newHRTFSet prepares the HRTF for the sample rate.
+func newHRTFSet(h *HRTF, sampleRate int) *hrtfSet {
+	s := &hrtfSet{}
+	for _, hrir := range h.HRIRs {
+		left := resampleImpulse(hrir.Left, h.SampleRate, sampleRate)
+		right := resampleImpulse(hrir.Right, h.SampleRate, sampleRate)
+		s.length = max(s.length, len(left), len(right))
+		az := hrir.Azimuth * math.Pi / 180
+		el := hrir.Elevation * math.Pi / 180
+		s.directions = append(s.directions, Vector{math.Cos(el) * math.Cos(az), math.Cos(el) * math.Sin(az), math.Sin(el)})
+		s.left = append(s.left, left)
+		s.right = append(s.right, right)
+	}
+	// Make all the impulse responses have the same length.
+	for i := range s.left {
+		s.left[i] = append(s.left[i], make([]float32, s.length-len(s.left[i]))...)
+		s.right[i] = append(s.right[i], make([]float32, s.length-len(s.right[i]))...)
+	}
+	return s
+}
+
+// resampleImpulse resamples the impulse response by linear interpolation.
+// The amplitude is scaled to keep the gain of the response.
+func resampleImpulse(ir []float32, srcSampleRate, dstSampleRate int) []float32 {
+	if srcSampleRate == dstSampleRate {
+		return append([]float32(nil), ir...)
+	}
+	ratio := float64(srcSampleRate) / float64(dstSampleRate)
+	n := int(math.Ceil(float64(len(ir)) / ratio))
+	dst := make([]float32, n)
+	for i := range dst {
+		pos := float64(i) * ratio
+		j := int(pos)
+		f := float32(pos - float64(j))
+		var v0, v1 float32
+		if j < len(ir) {
+			v0 = ir[j]
+		}
+		if j+1 < len(ir) {
+			v1 = ir[j+1]
+		}
+		dst[i] = (v0 + (v1-v0)*f) * float32(ratio)
+	}
+	return dst
+}
+
+// nearest returns the index of the HRIR nearest to the direction in SOFA's coordinates.
+func (s *hrtfSet) nearest(dir Vector) int {
+	var idx int
+	best := math.Inf(-1)
+	for i, d := range s.directions {
+		if v := d.dot(dir); v > best {
+			best = v
+			idx = i
+		}
+	}
+	return idx
+}
+
+// SetHRTF enables binaural rendering of the spatialized players with the HRTF.
+// The HRTF is used only when the mux has two channels. If h is nil, binaural rendering is disabled.
+func (m *Mux) SetHRTF(h *HRTF) {
+	if h == nil || len(h.HRIRs) == 0 {
+		m.hrtf.Store(nil)
+		return
+	}
+	m.hrtf.Store(newHRTFSet(h, m.sampleRate))
+}
+
+// convolveAndAdd convolves mono with the HRIR and adds the result to buf in stereo.
+// The HRIR is crossfaded from the previous one not to make click noises when the direction changes.
+//
+// When convolveAndAdd is called, the mutex renderM must be locked.
+func (p *playerImpl) convolveAndAdd(buf []float32, mono []float32, hrtf *hrtfSet, prevGain, gain float32) {
+	s := &p.spatial
+	frames := len(mono)
+	n := hrtf.length
+
+	if s.hrtf != hrtf {
+		// The HRTF is changed. Forget the history.
+		s.hrtf = hrtf
+		if cap(s.history) < n-1 {
+			s.history = make([]float32, n-1)
+		}
+		s.history = s.history[:n-1]
+		clear(s.history)
+		s.prevHRIR = s.hrir
+	}
+
+	// ext is the history and the current input.
+	if cap(s.ext) < n-1+frames {
+		s.ext = make([]float32, n-1+frames)
+	}
+	ext := s.ext[:n-1+frames]
+	copy(ext, s.history)
+	copy(ext[n-1:], mono)
+
+	l0, r0 := hrtf.left[s.prevHRIR], hrtf.right[s.prevHRIR]
+	l1, r1 := hrtf.left[s.hrir], hrtf.right[s.hrir]
+	crossfade := s.prevHRIR != s.hrir
+	for j := range frames {
+		x := ext[j : j+n]
+		var l, r float32
+		for k := range n {
+			v := x[n-1-k]
+			l += l1[k] * v
+			r += r1[k] * v
+		}
+		rate := float32(j) / float32(frames)
+		if crossfade {
+			var pl, pr float32
+			for k := range n {
+				v := x[n-1-k]
+				pl += l0[k] * v
+				pr += r0[k] * v
+			}
+			l = pl + (l-pl)*rate
+			r = pr + (r-pr)*rate
+		}
+		g := prevGain + (gain-prevGain)*rate
+		buf[2*j] += l * g
+		buf[2*j+1] += r * g
+	}
+
+	copy(s.history, ext[frames:])
+	s.prevHRIR = s.hrir
+}
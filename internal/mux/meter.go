@@ -0,0 +1,127 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMeterDecay is the default time constant of meters.
+const DefaultMeterDecay = 300 * time.Millisecond
+
+// Meter is a snapshot of a meter.
+type Meter struct {
+	// Peak is the peak absolute value of the samples, decaying exponentially.
+	Peak float64
+
+	// RMS is the root mean square of the samples, averaged exponentially.
+	RMS float64
+
+	// Clips is the number of the samples whose absolute values exceeded 1.
+	Clips int64
+}
+
+// meter measures samples on the render path. The values can be read from any goroutine.
+type meter struct {
+	peak  atomicFloat64
+	rms   atomicFloat64
+	clips atomic.Int64
+
+	// meanSquare is the averaged mean square. meanSquare is used only on the render path.
+	meanSquare float64
+}
+
+// meterBlock accumulates the samples of one buffer.
+type meterBlock struct {
+	peak       float32
+	sumSquares float32
+	clips      int
+}
+
+// add adds the samples multiplied by the gain.
+func (b *meterBlock) add(samples []float32, gain float32) {
+	for _, v := range samples {
+		v *= gain
+		if v < 0 {
+			v = -v
+		}
+		if v > b.peak {
+			b.peak = v
+		}
+		if v > 1 {
+			b.clips++
+		}
+		b.sumSquares += v * v
+	}
+}
+
+// update updates the meter by the accumulated samples of a buffer of the given number of samples.
+// coef is the decay coefficient for the buffer.
+func (m *meter) update(b *meterBlock, samples int, coef float64) {
+	peak := max(float64(b.peak), m.peak.Load()*coef)
+	m.peak.Store(peak)
+
+	var ms float64
+	if samples > 0 {
+		ms = float64(b.sumSquares) / float64(samples)
+	}
+	m.meanSquare = m.meanSquare*coef + ms*(1-coef)
+	m.rms.Store(math.Sqrt(m.meanSquare))
+
+	if b.clips > 0 {
+		m.clips.Add(int64(b.clips))
+	}
+}
+
+// Load returns the current values of the meter.
+func (m *meter) Load() Meter {
+	return Meter{
+		Peak:  m.peak.Load(),
+		RMS:   m.rms.Load(),
+		Clips: m.clips.Load(),
+	}
+}
+
+// meterCoef returns the decay coefficient for a buffer of the given number of samples in the mux's channels.
+func (m *Mux) meterCoef(samples int) float64 {
+	decay := time.Duration(m.meterDecay.Load())
+	if decay <= 0 {
+		return 0
+	}
+	d := float64(samples/m.channelCount) / float64(m.sampleRate)
+	return math.Exp(-d / decay.Seconds())
+}
+
+// MeterDecay returns the time constant of the meters.
+func (m *Mux) MeterDecay() time.Duration {
+	return time.Duration(m.meterDecay.Load())
+}
+
+// SetMeterDecay sets the time constant of the meters.
+// A peak decays and an RMS is averaged with the time constant.
+func (m *Mux) SetMeterDecay(decay time.Duration) {
+	m.meterDecay.Store(int64(decay))
+}
+
+// Meter returns the meter of the master output.
+func (m *Mux) Meter() Meter {
+	return m.meter.Load()
+}
+
+func (p *Player) Meter() Meter {
+	return p.p.meter.Load()
+}
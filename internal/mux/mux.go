@@ -23,7 +23,7 @@ import (
 	"math"
 	"runtime"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
 // Format must sync with oto's Format.
@@ -48,111 +48,320 @@ func (f Format) ByteLength() int {
 }
 
 // Mux is a low-level multiplexer of audio players.
+//
+// ReadFloat32s is the render path called by drivers. It never allocates and never waits for a lock:
+// the set of players is read from a preallocated snapshot, and each player's samples are passed from
+// the source-reading side through a single-producer single-consumer ring buffer.
+//
+// Each player reads its source on its own goroutine, so a slow source never delays the other players.
+// The goroutine sleeps until the render path consumes the player's samples or the player's state changes.
+// As no goroutine is shared by players, the players are not scheduled by their buffers' fill levels:
+// a drained buffer is refilled as soon as its own goroutine is woken.
 type Mux struct {
 	sampleRate   int
 	channelCount int
+	channelMask  ChannelMask
 	format       Format
 
+	// outputChannelCount is the number of the channels passed to ReadFloat32s.
+	// If the output has fewer channels than the mux, the mixed samples are downmixed by outputMatrix.
+	outputChannelCount int
+	outputMatrix       []float32
+	mixBuf             []float32
+
 	players map[*playerImpl]struct{}
-	cond    *sync.Cond
+	m       sync.Mutex
+
+	// closed reports whether the mux is closed. No player can play after the mux is closed.
+	closed bool
+
+	// oneShots holds players played by PlayOnce not to be collected until they finish.
+	oneShots map[*playerImpl]*Player
+
+	// snapshot is a copy of the players set for the render path, in the order the players were added.
+	// The order makes the mixed output deterministic.
+	// snapshot is replaced whenever the set is modified, and never modified in place.
+	snapshot atomic.Pointer[[]*playerImpl]
+
+	// volume is the master volume. muted reports whether the output is muted regardless of volume.
+	volume atomicFloat64
+	muted  atomic.Bool
+
+	// prevVolume is the effective master volume applied to the last buffer. prevVolume is used only by ReadFloat32s.
+	prevVolume float64
+
+	// listener is the listener for the spatialized players.
+	// speakers is the speaker positions for panning.
+	listener atomic.Pointer[Listener]
+	speakers []speaker
+
+	// hrtf is the HRTF for binaural rendering of the spatialized players.
+	hrtf atomic.Pointer[hrtfSet]
+
+	// meter is the meter of the master output. meterDecay is the time constant of all the meters.
+	meter      meter
+	meterDecay atomic.Int64
+
+	// analyzer is the analyzer of the master output.
+	analyzer atomic.Pointer[Analyzer]
+
+	// playM is read-locked while a player is starting to play asynchronously.
+	playM sync.RWMutex
+
+	// buses is the buses for the render path.
+	// buses is replaced whenever a bus is added, and never modified in place.
+	buses atomic.Pointer[[]*Bus]
+
+	// taps is the output taps for the render path.
+	// taps is replaced whenever a tap is added or removed, and never modified in place.
+	taps atomic.Pointer[[]*OutputTap]
 }
 
-// New creates a new Mux.
+// MaxVolume is the maximum volume of the mux and players. MaxVolume is about +12 dB.
+const MaxVolume = 4
+
+func clampVolume(volume float64) float64 {
+	return min(max(volume, 0), MaxVolume)
+}
+
+// New creates a new Mux with the default speaker positions for the channel count.
 func New(sampleRate int, channelCount int, format Format) *Mux {
+	return NewWithChannelMask(sampleRate, channelCount, format, 0)
+}
+
+// NewWithChannelMask creates a new Mux with the speaker positions of the channels.
+// If channelMask is 0, the default positions for channelCount are used.
+func NewWithChannelMask(sampleRate int, channelCount int, format Format, channelMask ChannelMask) *Mux {
+	if channelMask == 0 {
+		channelMask = DefaultChannelMask(channelCount)
+	}
 	m := &Mux{
-		sampleRate:   sampleRate,
-		channelCount: channelCount,
-		format:       format,
-		cond:         sync.NewCond(&sync.Mutex{}),
+		sampleRate:         sampleRate,
+		channelCount:       channelCount,
+		channelMask:        channelMask,
+		format:             format,
+		outputChannelCount: channelCount,
+		prevVolume:         1,
 	}
-	go m.loop()
+	m.snapshot.Store(&[]*playerImpl{})
+	m.buses.Store(&[]*Bus{})
+	m.taps.Store(&[]*OutputTap{})
+	m.volume.Store(1)
+	m.listener.Store(&Listener{})
+	m.meterDecay.Store(int64(DefaultMeterDecay))
+	m.speakers = newSpeakers(channelCount, channelMask)
 	return m
 }
 
-func (m *Mux) shouldWait() bool {
-	for p := range m.players {
-		if p.canReadSourceToBuffer() {
-			return false
-		}
+// addPlayer adds the player to the players set.
+// addPlayer returns false if the mux is already closed.
+func (m *Mux) addPlayer(player *playerImpl) bool {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if m.closed {
+		return false
+	}
+	if _, ok := m.players[player]; ok {
+		return true
+	}
+	if m.players == nil {
+		m.players = map[*playerImpl]struct{}{}
+	}
+	m.players[player] = struct{}{}
+
+	players := make([]*playerImpl, 0, len(m.players))
+	players = append(players, *m.snapshot.Load()...)
+	players = append(players, player)
+	m.snapshot.Store(&players)
+
+	if !player.isDirect() || player.oneShot.Load() {
+		go player.pump()
 	}
 	return true
 }
 
-func (m *Mux) wait() {
-	m.cond.L.Lock()
-	defer m.cond.L.Unlock()
+func (m *Mux) addOneShot(player *Player) {
+	m.m.Lock()
+	defer m.m.Unlock()
 
-	for m.shouldWait() {
-		m.cond.Wait()
+	if m.oneShots == nil {
+		m.oneShots = map[*playerImpl]*Player{}
 	}
+	m.oneShots[player.p] = player
 }
 
-func (m *Mux) loop() {
-	var players []*playerImpl
-	for {
-		m.wait()
+func (m *Mux) removePlayer(player *playerImpl) {
+	m.m.Lock()
+	defer m.m.Unlock()
 
-		m.cond.L.Lock()
-		for i := range players {
-			players[i] = nil
-		}
-		players = players[:0]
-		for p := range m.players {
-			players = append(players, p)
-		}
-		m.cond.L.Unlock()
+	delete(m.oneShots, player)
 
-		allZero := true
-		for _, p := range players {
-			n := p.readSourceToBuffer()
-			if n != 0 {
-				allZero = false
-			}
-		}
+	if _, ok := m.players[player]; !ok {
+		return
+	}
+	delete(m.players, player)
 
-		// Sleeping is necessary especially on browsers.
-		// Sometimes a player continues to read 0 bytes from the source and this loop can be a busy loop in such case.
-		if allZero {
-			time.Sleep(time.Millisecond)
+	players := make([]*playerImpl, 0, len(m.players))
+	for _, p := range *m.snapshot.Load() {
+		if p != player {
+			players = append(players, p)
 		}
 	}
+	m.snapshot.Store(&players)
 }
 
-func (m *Mux) addPlayer(player *playerImpl) {
-	m.cond.L.Lock()
-	defer m.cond.L.Unlock()
+// errMuxClosed is the error of a player played after the mux is closed.
+var errMuxClosed = errors.New("mux: the mux is closed")
 
-	if m.players == nil {
-		m.players = map[*playerImpl]struct{}{}
+// Close closes the mux. Close closes all the playing players and the output taps.
+// After Close, a player fails to play with an error, and ReadFloat32s outputs silence.
+//
+// Close is concurrent-safe.
+func (m *Mux) Close() error {
+	m.m.Lock()
+	if m.closed {
+		m.m.Unlock()
+		return nil
 	}
-	m.players[player] = struct{}{}
-	m.cond.Signal()
-}
+	m.closed = true
+	players := *m.snapshot.Load()
+	oneShots := make([]*Player, 0, len(m.oneShots))
+	for _, p := range m.oneShots {
+		oneShots = append(oneShots, p)
+	}
+	taps := *m.taps.Load()
+	m.m.Unlock()
 
-func (m *Mux) removePlayer(player *playerImpl) {
-	m.cond.L.Lock()
-	defer m.cond.L.Unlock()
+	// Close the players without the mutex m as closing a player locks it.
+	for _, p := range players {
+		_ = p.Close()
+	}
+	// A one-shot player might not start playing yet.
+	for _, p := range oneShots {
+		_ = p.Close()
+	}
 
-	delete(m.players, player)
-	m.cond.Signal()
+	var err error
+	for _, t := range taps {
+		if err0 := t.Close(); err0 != nil && err == nil {
+			err = err0
+		}
+	}
+	return err
 }
 
 // ReadFloat32s fills buf with the multiplexed data of the players as float32 values.
+//
+// ReadFloat32s doesn't allocate memory and doesn't block.
+// ReadFloat32s must not be called concurrently.
 func (m *Mux) ReadFloat32s(buf []float32) {
-	m.cond.L.Lock()
-	players := make([]*playerImpl, 0, len(m.players))
-	for p := range m.players {
-		players = append(players, p)
+	if m.outputMatrix == nil {
+		m.mix(buf)
+		m.updateMeter(buf)
+		m.writeToTaps(buf)
+		return
 	}
-	m.cond.L.Unlock()
 
-	for i := range buf {
-		buf[i] = 0
+	// The buffer grows only when the driver passes a larger buffer than before.
+	n := len(buf) / m.outputChannelCount * m.channelCount
+	if cap(m.mixBuf) < n {
+		m.mixBuf = make([]float32, n)
 	}
-	for _, p := range players {
-		p.readBufferAndAdd(buf)
+	m.mix(m.mixBuf[:n])
+	applyMatrix(buf, m.mixBuf[:n], m.outputMatrix, m.channelCount, m.outputChannelCount)
+	m.updateMeter(buf)
+	m.writeToTaps(buf)
+}
+
+// updateMeter updates the master meter with the output.
+func (m *Mux) updateMeter(buf []float32) {
+	var b meterBlock
+	b.add(buf, 1)
+	m.meter.update(&b, len(buf), m.meterCoef(len(buf)/m.outputChannelCount*m.channelCount))
+	m.analyzer.Load().write(buf, 1, m.outputChannelCount)
+}
+
+// mix fills buf with the multiplexed data of the players in the mux's channels.
+func (m *Mux) mix(buf []float32) {
+	clear(buf)
+
+	volume := m.volume.Load()
+	if m.muted.Load() {
+		volume = 0
+	}
+	prevVolume := m.prevVolume
+	m.prevVolume = volume
+
+	// While the output is silent, all the players are virtual voices.
+	inaudible := prevVolume == 0 && volume == 0
+	buses := *m.buses.Load()
+	for _, b := range buses {
+		b.begin(len(buf), inaudible)
+	}
+	for _, p := range *m.snapshot.Load() {
+		if b := p.bus; b != nil {
+			p.readBufferAndAdd(b.buf, b.inaudible)
+			continue
+		}
+		p.readBufferAndAdd(buf, inaudible)
+	}
+	for _, b := range buses {
+		b.end(buf)
+	}
+
+	if inaudible || (prevVolume == 1 && volume == 1) {
+		return
 	}
-	m.cond.Signal()
+	// Ramp the master volume over the buffer not to make a click noise.
+	applyVolume(buf, float32(prevVolume), float32(volume), m.channelCount)
+}
+
+// ChannelMask returns the speaker positions of the mux's channels.
+func (m *Mux) ChannelMask() ChannelMask {
+	return m.channelMask
+}
+
+// SetOutputChannels makes ReadFloat32s output samples with the channels at the speaker positions.
+// This is used when the device has fewer channels than the mux. The mixed samples are downmixed to the output.
+// If channelMask is 0, the default positions for channelCount are used.
+//
+// SetOutputChannels must be called before ReadFloat32s is called.
+func (m *Mux) SetOutputChannels(channelCount int, channelMask ChannelMask) {
+	if channelMask == 0 {
+		channelMask = DefaultChannelMask(channelCount)
+	}
+	m.outputChannelCount = channelCount
+	m.outputMatrix = nil
+	if channelCount != m.channelCount || channelMask != m.channelMask {
+		m.outputMatrix = ChannelMatrix(m.channelCount, m.channelMask, channelCount, channelMask)
+	}
+}
+
+// Volume returns the master volume.
+func (m *Mux) Volume() float64 {
+	return m.volume.Load()
+}
+
+// SetVolume sets the master volume. The volume is clamped to [0, MaxVolume].
+func (m *Mux) SetVolume(volume float64) {
+	m.volume.Store(clampVolume(volume))
+}
+
+// IsMuted reports whether the output is muted.
+func (m *Mux) IsMuted() bool {
+	return m.muted.Load()
+}
+
+// SetMuted mutes or unmutes the output. The master volume is kept while the output is muted.
+func (m *Mux) SetMuted(muted bool) {
+	m.muted.Store(muted)
+}
+
+// SampleSource is a source of samples as float32 values.
+// This must sync with oto's SampleSource.
+type SampleSource interface {
+	ReadSamples(buf []float32) (int, error)
 }
 
 type Player struct {
@@ -160,7 +369,7 @@ type Player struct {
 	cleanup runtime.Cleanup
 }
 
-type playerState int
+type playerState int32
 
 const (
 	playerPaused playerState = iota
@@ -168,29 +377,220 @@ const (
 	playerClosed
 )
 
+type atomicPlayerState struct {
+	v atomic.Int32
+}
+
+func (a *atomicPlayerState) Load() playerState {
+	return playerState(a.v.Load())
+}
+
+func (a *atomicPlayerState) Store(s playerState) {
+	a.v.Store(int32(s))
+}
+
+func (a *atomicPlayerState) CompareAndSwap(old, new playerState) bool {
+	return a.v.CompareAndSwap(int32(old), int32(new))
+}
+
+type atomicFloat64 struct {
+	v atomic.Uint64
+}
+
+func (a *atomicFloat64) Load() float64 {
+	return math.Float64frombits(a.v.Load())
+}
+
+func (a *atomicFloat64) Store(v float64) {
+	a.v.Store(math.Float64bits(v))
+}
+
+// playerImpl is the implementation of Player.
+//
+// There are three mutexes:
+//
+//   - readM serializes accesses to the source and writes to the ring buffer.
+//   - m protects the player's state on the control side.
+//   - renderM protects the fields used on the render path. The render path only tries to lock renderM,
+//     and skips the player for the moment if it fails. The control side locks renderM only for a short time
+//     e.g. to reset the ring buffer.
+//
+// The locking order is readM, m, and then renderM.
 type playerImpl struct {
-	mux        *Mux
-	src        io.Reader
-	prevVolume float64
-	volume     float64
+	mux     *Mux
+	src     io.Reader
+	samples SampleSource
+	render  func(buf []float32)
+
+	// format, sampleRate and channelCount are the source's.
+	format       Format
+	sampleRate   int
+	channelCount int
+	loop         bool
+
+	state  atomicPlayerState
+	volume atomicFloat64
+	eof    atomic.Bool
+
+	// The fields below are protected by m.
 	err        error
-	state      playerState
-	buf        []byte
-	eof        bool
 	bufferSize int
 
-	m sync.Mutex
+	// remaining is the bytes of an incomplete frame read from src.
+	// remainingSamples is the samples of an incomplete frame read from samples.
+	// converter converts the read frames to the mux's layout.
+	// emptySinceLoop reports whether nothing has been read since the source was rewound for looping.
+	// These are protected by m.
+	remaining        []byte
+	remainingSamples []float32
+	converter        converter
+	emptySinceLoop   bool
+
+	// ring is a ring buffer of the samples read from the source.
+	// The source-reading side writes to [tail, head+len(ring)), and the render path reads [head, tail).
+	// ring itself is replaced only when both m and renderM are locked.
+	ring []float32
+	head atomic.Int64
+	tail atomic.Int64
+
+	// The fields below are used only on the render path, and protected by renderM.
+	prevVolume float64
+	renderBuf  []float32
+
+	// meter is the meter of the player's samples after the volume.
+	// analyzer is the analyzer attached to the player.
+	meter    meter
+	analyzer atomic.Pointer[Analyzer]
+
+	// emitter is the spatial properties of the player. If emitter is nil, the player is not spatialized.
+	// spatial is the state for spatialization, and protected by renderM.
+	emitter atomic.Pointer[Emitter]
+	spatial spatialState
+
+	// sound is the shared samples for a player created from a SoundBuffer.
+	// soundPos is the position in sound, and protected by renderM.
+	sound    *SoundBuffer
+	soundPos int
+
+	// bus is the bus the player is mixed into, or nil.
+	bus *Bus
+
+	// oneShot reports whether the player is closed automatically when it finishes.
+	oneShot atomic.Bool
+
+	// wakeCh wakes the goroutine reading the source.
+	wakeCh chan struct{}
+
+	readM   sync.Mutex
+	m       sync.Mutex
+	renderM sync.Mutex
+}
+
+// PlayerOptions represents options for a player.
+type PlayerOptions struct {
+	// SampleRate, ChannelCount and Format are the source's.
+	// The source is converted to the mux's sample rate and channel count.
+	// Format is ignored for a SampleSource.
+	SampleRate   int
+	ChannelCount int
+	Format       Format
+
+	// ChannelMask is the speaker positions of the source's channels.
+	// If ChannelMask is 0, the default positions for ChannelCount are used.
+	ChannelMask ChannelMask
+
+	// ChannelMatrix is the mixing matrix from the source's channels to the mux's channels.
+	// See ChannelMatrix for the layout. If ChannelMatrix is not nil, ChannelMask is ignored.
+	ChannelMatrix []float32
+
+	// BufferSize is the byte size of the buffer in the source's format.
+	// If 0 is specified, the default buffer size is used.
+	BufferSize int
+
+	// Volume is the initial volume.
+	Volume float64
+
+	// Loop specifies whether the source is rewound at its end. This requires the source to implement io.Seeker.
+	Loop bool
+
+	// Bus is the bus the player is mixed into. Bus must be created by the same mux.
+	// If Bus is nil, the player is mixed into the output directly.
+	Bus *Bus
 }
 
+// defaultPlayerOptions returns the options for a source in the mux's format.
+func (m *Mux) defaultPlayerOptions() *PlayerOptions {
+	return &PlayerOptions{
+		SampleRate:   m.sampleRate,
+		ChannelCount: m.channelCount,
+		Format:       m.format,
+		ChannelMask:  m.channelMask,
+		Volume:       1,
+	}
+}
+
+// NewPlayer creates a new player reading bytes from src in the mux's format.
+//
+// If src implements SampleSource, the player reads float32 values from src directly.
 func (m *Mux) NewPlayer(src io.Reader) *Player {
+	return m.NewPlayerWithOptions(src, nil)
+}
+
+// NewPlayerWithOptions creates a new player reading bytes from src with the given options.
+// If options is nil, the source is assumed to be in the mux's format.
+//
+// If src implements SampleSource, the player reads float32 values from src directly.
+func (m *Mux) NewPlayerWithOptions(src io.Reader, options *PlayerOptions) *Player {
+	p := &playerImpl{
+		src: src,
+	}
+	if s, ok := src.(SampleSource); ok {
+		p.src = nil
+		p.samples = s
+	}
+	return m.newPlayer(p, options)
+}
+
+// NewPlayerFromSampleSource creates a new player reading float32 values from src.
+func (m *Mux) NewPlayerFromSampleSource(src SampleSource) *Player {
+	return m.newPlayer(&playerImpl{
+		samples: src,
+	}, nil)
+}
+
+// NewPlayerFromRenderFunc creates a new player that calls render on the render path to fill its samples.
+func (m *Mux) NewPlayerFromRenderFunc(render func(buf []float32)) *Player {
+	return m.newPlayer(&playerImpl{
+		render: render,
+	}, nil)
+}
+
+func (m *Mux) newPlayer(p *playerImpl, options *PlayerOptions) *Player {
+	if options == nil {
+		options = m.defaultPlayerOptions()
+	}
+
+	p.mux = m
+	p.wakeCh = make(chan struct{}, 1)
+	p.format = options.Format
+	if p.samples != nil {
+		// The format of a SampleSource is always float32.
+		p.format = FormatFloat32LE
+	}
+	p.sampleRate = options.SampleRate
+	p.channelCount = options.ChannelCount
+	p.loop = options.Loop
+	p.bus = options.Bus
+	p.converter = m.newConverter(options)
+	p.prevVolume = clampVolume(options.Volume)
+	p.volume.Store(p.prevVolume)
+	p.bufferSize = options.BufferSize
+	if p.bufferSize == 0 {
+		p.bufferSize = p.defaultBufferSize()
+	}
+
 	pl := &Player{
-		p: &playerImpl{
-			mux:        m,
-			src:        src,
-			prevVolume: 1,
-			volume:     1,
-			bufferSize: m.defaultBufferSize(),
-		},
+		p: p,
 	}
 	pl.cleanup = runtime.AddCleanup(pl, func(p *playerImpl) {
 		_ = p.Close()
@@ -213,16 +613,35 @@ func (p *Player) Play() {
 	p.p.Play()
 }
 
+// PlayOnce plays the player, and closes the player automatically when the player finishes.
+//
+// The mux holds the player until the player finishes, so the player keeps playing even if it is not referenced.
+// A looping player never finishes and must be closed explicitly.
+//
+// PlayOnce must be called only for a new player.
+func (p *Player) PlayOnce() {
+	p.p.mux.addOneShot(p)
+	p.p.oneShot.Store(true)
+	p.p.Play()
+}
+
 func (p *playerImpl) Play() {
-	// Goroutines don't work efficiently on Windows. Avoid using them (hajimehoshi/ebiten#1768).
+	// Starting a goroutine for every Play doesn't work efficiently on Windows (hajimehoshi/ebiten#1768).
+	// Fill the buffer on the caller's goroutine instead. The player's pump goroutine is long-lived and refills the buffer later.
 	if runtime.GOOS == "windows" {
+		p.readM.Lock()
+		defer p.readM.Unlock()
 		p.m.Lock()
 		defer p.m.Unlock()
 
 		p.playImpl()
 	} else {
 		ch := make(chan struct{})
+		p.mux.playM.RLock()
 		go func() {
+			defer p.mux.playM.RUnlock()
+			p.readM.Lock()
+			defer p.readM.Unlock()
 			p.m.Lock()
 			defer p.m.Unlock()
 
@@ -243,7 +662,68 @@ func (p *playerImpl) setBufferSize(bufferSize int) {
 
 	p.bufferSize = bufferSize
 	if bufferSize == 0 {
-		p.bufferSize = p.mux.defaultBufferSize()
+		p.bufferSize = p.defaultBufferSize()
+	}
+	p.wake()
+}
+
+// isDirect reports whether the player generates its samples on the render path without a buffer.
+func (p *playerImpl) isDirect() bool {
+	return p.render != nil || p.sound != nil
+}
+
+// wake wakes the goroutine reading the source. wake never blocks.
+func (p *playerImpl) wake() {
+	select {
+	case p.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// pump reads the source to the buffer until the player is closed.
+// pump runs on its own goroutine for each player, and sleeps while there is nothing to do.
+//
+// pump also closes a one-shot player when it finishes.
+func (p *playerImpl) pump() {
+	for {
+		for p.readSourceToBuffer() > 0 {
+		}
+		if p.state.Load() == playerClosed {
+			return
+		}
+		if p.oneShot.Load() && p.state.Load() == playerPaused {
+			_ = p.Close()
+			return
+		}
+		<-p.wakeCh
+	}
+}
+
+// FillBuffers reads the sources of the players until their buffers are full, on the caller's goroutine.
+//
+// FillBuffers is used to render the output faster than real time deterministically.
+// Without FillBuffers, the players' buffers are filled asynchronously and might not catch up with rendering.
+func (m *Mux) FillBuffers() {
+	// Wait for the players starting to play.
+	m.playM.Lock()
+	m.playM.Unlock()
+
+	for _, p := range *m.snapshot.Load() {
+		for p.readSourceToBuffer() > 0 {
+		}
+	}
+}
+
+// finish pauses the player as the player reaches the end.
+//
+// finish never blocks.
+func (p *playerImpl) finish() {
+	if !p.state.CompareAndSwap(playerPlay, playerPaused) {
+		return
+	}
+	// Let the goroutine close the one-shot player.
+	if p.oneShot.Load() {
+		p.wake()
 	}
 }
 
@@ -266,24 +746,204 @@ func getBufferFromPool(size int) *[]byte {
 	return buf
 }
 
-// read reads the source to buf.
-// read unlocks the mutex temporarily and locks when reading finishes.
+var theSamplesPool = sync.Pool{
+	New: func() any {
+		var buf []float32
+		return &buf
+	},
+}
+
+func getSamplesFromPool(size int) *[]float32 {
+	buf := theSamplesPool.Get().(*[]float32)
+
+	if cap(*buf) < size {
+		*buf = make([]float32, size)
+	}
+
+	*buf = (*buf)[:size]
+
+	return buf
+}
+
+// bufferSizeInSamples returns the buffer size as the number of float32 values after the conversion,
+// aligned to whole frames.
+// The buffer is large enough to read at least one source frame when it is empty, or reading would stall.
+//
+// When bufferSizeInSamples is called, the mutex m must be locked.
+func (p *playerImpl) bufferSizeInSamples() int {
+	frames := p.bufferSize / (p.format.ByteLength() * p.channelCount)
+	frames = int(int64(frames) * int64(p.mux.sampleRate) / int64(p.sampleRate))
+	return max(frames, p.converter.minDstFrames()) * p.mux.channelCount
+}
+
+// bufferedSamples returns the number of the samples in the ring buffer.
+func (p *playerImpl) bufferedSamples() int {
+	return int(p.tail.Load() - p.head.Load())
+}
+
+// ensureRing allocates or resizes the ring buffer if needed.
+//
+// When ensureRing is called, the mutexes readM and m must be locked.
+func (p *playerImpl) ensureRing() {
+	size := p.bufferSizeInSamples()
+	if len(p.ring) == size {
+		return
+	}
+	// Don't shrink the ring buffer while the unread samples don't fit with the new size.
+	if p.ring != nil && p.bufferedSamples() > size {
+		return
+	}
+
+	ring := (*getSamplesFromPool(size))[:size]
+
+	p.renderM.Lock()
+	defer p.renderM.Unlock()
+
+	head, tail := p.head.Load(), p.tail.Load()
+	var n int
+	for i := head; i < tail; i++ {
+		ring[n] = p.ring[int(i%int64(len(p.ring)))]
+		n++
+	}
+	if p.ring != nil {
+		old := p.ring
+		theSamplesPool.Put(&old)
+	}
+	p.ring = ring
+	p.head.Store(0)
+	p.tail.Store(int64(n))
+}
+
+// writeToRing writes the samples to the ring buffer.
+// The caller must ensure that the ring buffer has enough space for buf.
+//
+// When writeToRing is called, the mutexes readM and m must be locked.
+func (p *playerImpl) writeToRing(buf []float32) {
+	tail := p.tail.Load()
+	for len(buf) > 0 {
+		idx := int(tail % int64(len(p.ring)))
+		n := copy(p.ring[idx:], buf)
+		buf = buf[n:]
+		tail += int64(n)
+	}
+	p.tail.Store(tail)
+}
+
+// read reads the source and writes the converted samples to the ring buffer.
+// read unlocks the mutex m temporarily and locks when reading finishes.
 // This avoids locking during an external function call Read (#188).
 //
-// When read is called, the mutex m must be locked.
-func (p *playerImpl) read(buf []byte) (int, error) {
-	p.m.Unlock()
-	defer p.m.Lock()
-	return p.src.Read(buf)
+// When read is called, the mutexes readM and m must be locked.
+// As readM is kept locked, the source and the ring buffer are never accessed concurrently by other producers.
+func (p *playerImpl) read() (int, error) {
+	p.ensureRing()
+	spaceFrames := (len(p.ring) - p.bufferedSamples()) / p.mux.channelCount
+	frames := p.converter.sourceFrames(spaceFrames)
+	if frames <= 0 {
+		return 0, nil
+	}
+
+	var n int
+	var err error
+	if p.samples != nil {
+		buf := getSamplesFromPool(frames*p.channelCount - len(p.remainingSamples))
+		defer theSamplesPool.Put(buf)
+
+		p.m.Unlock()
+		n, err = p.samples.ReadSamples(*buf)
+		p.m.Lock()
+
+		// The player might be closed during reading.
+		if p.state.Load() == playerClosed {
+			return n, err
+		}
+
+		src := (*buf)[:n]
+		if len(p.remainingSamples) > 0 {
+			p.remainingSamples = append(p.remainingSamples, src...)
+			src = p.remainingSamples
+		}
+		m := len(src) / p.channelCount * p.channelCount
+		p.writeConverted(src[:m])
+		p.remainingSamples = append(p.remainingSamples[:0], src[m:]...)
+	} else {
+		bytesPerFrame := p.format.ByteLength() * p.channelCount
+		buf := getBufferFromPool(frames*bytesPerFrame - len(p.remaining))
+		defer theBufPool.Put(buf)
+
+		p.m.Unlock()
+		n, err = p.src.Read(*buf)
+		p.m.Lock()
+
+		// The player might be closed during reading.
+		if p.state.Load() == playerClosed {
+			return n, err
+		}
+
+		src := (*buf)[:n]
+		if len(p.remaining) > 0 {
+			p.remaining = append(p.remaining, src...)
+			src = p.remaining
+		}
+		m := len(src) / bytesPerFrame * bytesPerFrame
+
+		decoded := getSamplesFromPool(m / p.format.ByteLength())
+		defer theSamplesPool.Put(decoded)
+		*decoded = p.format.appendDecoded((*decoded)[:0], src[:m])
+		p.writeConverted(*decoded)
+
+		p.remaining = append(p.remaining[:0], src[m:]...)
+	}
+
+	if n > 0 {
+		p.emptySinceLoop = false
+	}
+	if err == io.EOF && p.loop && !p.emptySinceLoop {
+		if s := p.seeker(); s != nil {
+			if _, err := s.Seek(0, io.SeekStart); err != nil {
+				return n, err
+			}
+			p.emptySinceLoop = true
+			return n, nil
+		}
+	}
+	return n, err
+}
+
+// writeConverted converts the samples to the mux's layout and writes them to the ring buffer.
+//
+// When writeConverted is called, the mutexes readM and m must be locked.
+func (p *playerImpl) writeConverted(samples []float32) {
+	if p.converter.isIdentity() {
+		p.writeToRing(samples)
+		return
+	}
+	converted := getSamplesFromPool(0)
+	defer theSamplesPool.Put(converted)
+	*converted = p.converter.appendConverted((*converted)[:0], samples)
+	p.writeToRing(*converted)
+}
+
+// seeker returns the source as an io.Seeker, or nil if the source doesn't implement io.Seeker.
+func (p *playerImpl) seeker() io.Seeker {
+	var s io.Seeker
+	switch {
+	case p.src != nil:
+		s, _ = p.src.(io.Seeker)
+	case p.samples != nil:
+		s, _ = p.samples.(io.Seeker)
+	}
+	return s
 }
 
 // addToPlayers adds p to the players set.
+// addToPlayers returns false if the mux is already closed.
 //
 // When addToPlayers is called, the mutex m must be locked.
-func (p *playerImpl) addToPlayers() {
+func (p *playerImpl) addToPlayers() bool {
 	p.m.Unlock()
 	defer p.m.Lock()
-	p.mux.addPlayer(p)
+	return p.mux.addPlayer(p)
 }
 
 // removeFromPlayers removes p from the players set.
@@ -295,43 +955,53 @@ func (p *playerImpl) removeFromPlayers() {
 	p.mux.removePlayer(p)
 }
 
+// playImpl starts playing.
+//
+// When playImpl is called, the mutexes readM and m must be locked.
 func (p *playerImpl) playImpl() {
 	if p.err != nil {
 		return
 	}
-	if p.state != playerPaused {
+	if p.state.Load() != playerPaused {
 		return
 	}
-	p.state = playerPlay
-
-	if !p.eof {
-		buf := getBufferFromPool(p.bufferSize)
-		defer theBufPool.Put(buf)
-
-		if p.buf == nil {
-			p.buf = (*getBufferFromPool(p.bufferSize))[:0]
-		}
+	p.state.Store(playerPlay)
 
-		for len(p.buf) < p.bufferSize {
-			n, err := p.read(*buf)
+	if !p.isDirect() && !p.eof.Load() {
+		for p.bufferedSamples() < p.bufferSizeInSamples() {
+			n, err := p.read()
 			if err != nil && err != io.EOF {
 				p.setErrorImpl(err)
 				return
 			}
-			p.buf = append(p.buf, (*buf)[:n]...)
 			if err == io.EOF {
-				p.eof = true
+				p.eof.Store(true)
 				break
 			}
+			// The source might not have data yet, e.g. a stream fed by a writer.
+			// Leave the rest to the pump not to block here.
+			if n == 0 {
+				break
+			}
+			// The player might be closed during reading.
+			if p.state.Load() == playerClosed {
+				return
+			}
 		}
 	}
 
-	if p.eof && len(p.buf) == 0 {
-		p.returnBufferToPool()
-		p.state = playerPaused
+	if p.eof.Load() && p.bufferedSamples() == 0 {
+		p.finish()
+	}
+	if p.sound != nil && !p.loop && p.isSoundFinished() {
+		p.finish()
 	}
 
-	p.addToPlayers()
+	if !p.addToPlayers() {
+		p.setErrorImpl(errMuxClosed)
+		return
+	}
+	p.wake()
 }
 
 func (p *Player) Pause() {
@@ -342,10 +1012,7 @@ func (p *playerImpl) Pause() {
 	p.m.Lock()
 	defer p.m.Unlock()
 
-	if p.state != playerPlay {
-		return
-	}
-	p.state = playerPaused
+	p.state.CompareAndSwap(playerPlay, playerPaused)
 }
 
 func (p *Player) Seek(offset int64, whence int) (int64, error) {
@@ -353,11 +1020,17 @@ func (p *Player) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (p *playerImpl) Seek(offset int64, whence int) (int64, error) {
+	if p.sound != nil {
+		return p.seekSound(offset, whence)
+	}
+
+	p.readM.Lock()
+	defer p.readM.Unlock()
 	p.m.Lock()
 	defer p.m.Unlock()
 
 	// If a player is playing, keep playing even after this seeking.
-	if p.state == playerPlay {
+	if p.state.Load() == playerPlay {
 		defer p.playImpl()
 	}
 
@@ -365,8 +1038,8 @@ func (p *playerImpl) Seek(offset int64, whence int) (int64, error) {
 	p.resetImpl()
 
 	// Check if the source implements io.Seeker.
-	s, ok := p.src.(io.Seeker)
-	if !ok {
+	s := p.seeker()
+	if s == nil {
 		return 0, errors.New("mux: the source must implement io.Seeker")
 	}
 	return s.Seek(offset, whence)
@@ -377,18 +1050,32 @@ func (p *Player) Reset() {
 }
 
 func (p *playerImpl) Reset() {
+	p.readM.Lock()
+	defer p.readM.Unlock()
 	p.m.Lock()
 	defer p.m.Unlock()
 	p.resetImpl()
 }
 
+// resetImpl pauses the player and clears the buffers.
+//
+// When resetImpl is called, the mutexes readM and m must be locked.
 func (p *playerImpl) resetImpl() {
-	if p.state == playerClosed {
+	if p.state.Load() == playerClosed {
 		return
 	}
-	p.state = playerPaused
-	p.buf = p.buf[:0]
-	p.eof = false
+	p.state.Store(playerPaused)
+
+	p.renderM.Lock()
+	p.head.Store(p.tail.Load())
+	p.renderM.Unlock()
+
+	p.remaining = p.remaining[:0]
+	p.remainingSamples = p.remainingSamples[:0]
+	p.converter.reset()
+	p.emptySinceLoop = false
+	p.eof.Store(false)
+	p.wake()
 }
 
 func (p *Player) IsPlaying() bool {
@@ -398,7 +1085,7 @@ func (p *Player) IsPlaying() bool {
 func (p *playerImpl) IsPlaying() bool {
 	p.m.Lock()
 	defer p.m.Unlock()
-	return p.state == playerPlay
+	return p.state.Load() == playerPlay
 }
 
 func (p *Player) Volume() float64 {
@@ -406,9 +1093,7 @@ func (p *Player) Volume() float64 {
 }
 
 func (p *playerImpl) Volume() float64 {
-	p.m.Lock()
-	defer p.m.Unlock()
-	return p.volume
+	return p.volume.Load()
 }
 
 func (p *Player) SetVolume(volume float64) {
@@ -418,9 +1103,13 @@ func (p *Player) SetVolume(volume float64) {
 func (p *playerImpl) SetVolume(volume float64) {
 	p.m.Lock()
 	defer p.m.Unlock()
-	p.volume = volume
-	if p.state != playerPlay {
+
+	volume = clampVolume(volume)
+	p.volume.Store(volume)
+	if p.state.Load() != playerPlay {
+		p.renderM.Lock()
 		p.prevVolume = volume
+		p.renderM.Unlock()
 	}
 }
 
@@ -428,10 +1117,14 @@ func (p *Player) BufferedSize() int {
 	return p.p.BufferedSize()
 }
 
+// BufferedSize returns the byte size of the buffered samples in the source format.
 func (p *playerImpl) BufferedSize() int {
 	p.m.Lock()
 	defer p.m.Unlock()
-	return len(p.buf)
+
+	frames := p.bufferedSamples() / p.mux.channelCount
+	frames = int(int64(frames) * int64(p.sampleRate) / int64(p.mux.sampleRate))
+	return frames*p.format.ByteLength()*p.channelCount + len(p.remaining) + len(p.remainingSamples)*p.format.ByteLength()
 }
 
 func (p *Player) Close() error {
@@ -445,134 +1138,231 @@ func (p *playerImpl) Close() error {
 	return p.closeImpl()
 }
 
+// closeImpl closes the player.
+//
+// When closeImpl is called, the mutex m must be locked.
 func (p *playerImpl) closeImpl() error {
 	p.removeFromPlayers()
 
-	if p.state == playerClosed {
+	if p.state.Load() == playerClosed {
 		return p.err
 	}
-	p.state = playerClosed
+	p.state.Store(playerClosed)
 	p.returnBufferToPool()
+	p.wake()
 
 	return p.err
 }
 
-func (p *playerImpl) readBufferAndAdd(buf []float32) int {
-	p.m.Lock()
-	defer p.m.Unlock()
+// readBufferAndAdd adds the buffered samples to buf.
+// If inaudible is true, the output is silent regardless of the player, and the player is treated as a virtual voice.
+//
+// readBufferAndAdd is called on the render path. readBufferAndAdd must not allocate or wait for locks.
+func (p *playerImpl) readBufferAndAdd(buf []float32, inaudible bool) int {
+	if e := p.emitter.Load(); e != nil {
+		return p.readSpatialAndAdd(buf, e, inaudible)
+	}
+	if p.render != nil {
+		return p.renderAndAdd(buf, inaudible)
+	}
+	if p.sound != nil {
+		return p.readSoundAndAdd(buf, inaudible)
+	}
+
+	// If renderM is locked, the control side is modifying the player e.g. resetting it.
+	// Skip the player this time instead of waiting.
+	if !p.renderM.TryLock() {
+		return 0
+	}
+	defer p.renderM.Unlock()
+
+	var mb meterBlock
+	defer p.meter.update(&mb, len(buf), p.mux.meterCoef(len(buf)))
+	analyzer := p.analyzer.Load()
 
-	if p.state != playerPlay {
+	if p.state.Load() != playerPlay {
 		return 0
 	}
 
-	format := p.mux.format
-	bitDepthInBytes := format.ByteLength()
-	n := min(len(p.buf)/bitDepthInBytes, len(buf))
+	// Load eof before tail. eof is stored after the last samples are written.
+	eof := p.eof.Load()
+	head, tail := p.head.Load(), p.tail.Load()
+	n := min(int(tail-head), len(buf))
 
 	prevVolume := float32(p.prevVolume)
-	volume := float32(p.volume)
-
-	channelCount := p.mux.channelCount
-	rateDenom := float32(n / channelCount)
-
-	src := p.buf[:n*bitDepthInBytes]
-
-	for i := range n {
-		var v float32
-		switch format {
-		case FormatFloat32LE:
-			v = math.Float32frombits(uint32(src[4*i]) | uint32(src[4*i+1])<<8 | uint32(src[4*i+2])<<16 | uint32(src[4*i+3])<<24)
-		case FormatUnsignedInt8:
-			v8 := src[i]
-			v = float32(v8-(1<<7)) / (1 << 7)
-		case FormatSignedInt16LE:
-			v16 := int16(src[2*i]) | (int16(src[2*i+1]) << 8)
-			v = float32(v16) / (1 << 15)
-		default:
-			panic(fmt.Sprintf("mux: unexpected format: %d", format))
-		}
-		if volume == prevVolume {
-			buf[i] += v * volume
+	volume := float32(p.volume.Load())
+
+	// An inaudible player is a virtual voice. Its position advances as usual, but its samples are not mixed.
+	if n > 0 && !inaudible && !(prevVolume == 0 && volume == 0) {
+		channelCount := p.mux.channelCount
+		idx := int(head % int64(len(p.ring)))
+		n0 := min(n, len(p.ring)-idx)
+		if n0 == n {
+			addWithVolume(buf[:n], p.ring[idx:idx+n], prevVolume, volume, channelCount)
+			mb.add(p.ring[idx:idx+n], volume)
+			analyzer.write(p.ring[idx:idx+n], volume, channelCount)
 		} else {
-			rate := float32(i/channelCount) / rateDenom
-			if rate > 1 {
-				rate = 1
-			}
-			buf[i] += v * (volume*rate + prevVolume*(1-rate))
+			// The samples wrap around the ring buffer. Split the volume ramp at the boundary.
+			midVolume := prevVolume + (volume-prevVolume)*float32(n0/channelCount)/float32(n/channelCount)
+			addWithVolume(buf[:n0], p.ring[idx:], prevVolume, midVolume, channelCount)
+			addWithVolume(buf[n0:n], p.ring[:n-n0], midVolume, volume, channelCount)
+			mb.add(p.ring[idx:], volume)
+			mb.add(p.ring[:n-n0], volume)
+			analyzer.write(p.ring[idx:], volume, channelCount)
+			analyzer.write(p.ring[:n-n0], volume, channelCount)
+		}
+	}
+	p.prevVolume = float64(volume)
+	p.head.Store(head + int64(n))
+
+	if eof {
+		if head+int64(n) == tail {
+			p.finish()
 		}
+	} else {
+		// Let the source be read even when nothing is consumed, e.g. the source had no data last time.
+		p.wake()
 	}
 
-	p.prevVolume = p.volume
+	return n
+}
 
-	copy(p.buf, p.buf[n*bitDepthInBytes:])
-	p.buf = p.buf[:len(p.buf)-n*bitDepthInBytes]
+// renderAndAdd calls the render function and adds its samples to buf.
+func (p *playerImpl) renderAndAdd(buf []float32, inaudible bool) int {
+	if !p.renderM.TryLock() {
+		return 0
+	}
+	defer p.renderM.Unlock()
+
+	var mb meterBlock
+	defer p.meter.update(&mb, len(buf), p.mux.meterCoef(len(buf)))
+	analyzer := p.analyzer.Load()
 
-	if p.eof && len(p.buf) == 0 {
-		p.returnBufferToPool()
-		p.state = playerPaused
+	if p.state.Load() != playerPlay {
+		return 0
 	}
 
-	return n
+	prevVolume := float32(p.prevVolume)
+	volume := float32(p.volume.Load())
+	p.prevVolume = float64(volume)
+
+	// renderBuf is allocated only when the driver requests a larger buffer than ever.
+	if cap(p.renderBuf) < len(buf) {
+		p.renderBuf = make([]float32, len(buf))
+	}
+	renderBuf := p.renderBuf[:len(buf)]
+	clear(renderBuf)
+	p.render(renderBuf)
+
+	// An inaudible player still renders so that its state advances, but its samples are not mixed.
+	if inaudible || (prevVolume == 0 && volume == 0) {
+		return len(buf)
+	}
+	addWithVolume(buf, renderBuf, prevVolume, volume, p.mux.channelCount)
+	mb.add(renderBuf, volume)
+	analyzer.write(renderBuf, volume, p.mux.channelCount)
+	return len(buf)
 }
 
-func (p *playerImpl) canReadSourceToBuffer() bool {
-	p.m.Lock()
-	defer p.m.Unlock()
+// addWithVolume adds src multiplied by the volume to dst.
+// The volume changes linearly from prevVolume to volume over src.
+func addWithVolume(dst, src []float32, prevVolume, volume float32, channelCount int) {
+	if volume == prevVolume {
+		for i, v := range src {
+			dst[i] += v * volume
+		}
+		return
+	}
 
-	if p.eof {
-		return false
+	rateDenom := float32(len(src) / channelCount)
+	for i, v := range src {
+		rate := float32(i/channelCount) / rateDenom
+		if rate > 1 {
+			rate = 1
+		}
+		dst[i] += v * (volume*rate + prevVolume*(1-rate))
+	}
+}
+
+// applyVolume multiplies buf by the volume changing linearly from prevVolume to volume.
+func applyVolume(buf []float32, prevVolume, volume float32, channelCount int) {
+	if volume == prevVolume {
+		for i := range buf {
+			buf[i] *= volume
+		}
+		return
+	}
+
+	rateDenom := float32(len(buf) / channelCount)
+	for i := range buf {
+		rate := float32(i/channelCount) / rateDenom
+		if rate > 1 {
+			rate = 1
+		}
+		buf[i] *= volume*rate + prevVolume*(1-rate)
 	}
-	return len(p.buf) < p.bufferSize
 }
 
 func (p *playerImpl) readSourceToBuffer() int {
+	if p.isDirect() {
+		return 0
+	}
+
+	p.readM.Lock()
+	defer p.readM.Unlock()
 	p.m.Lock()
 	defer p.m.Unlock()
 
 	if p.err != nil {
 		return 0
 	}
-	if p.state == playerClosed {
+	if p.state.Load() == playerClosed {
 		return 0
 	}
-
-	if len(p.buf) >= p.bufferSize {
+	if p.eof.Load() {
 		return 0
 	}
 
-	buf := getBufferFromPool(p.bufferSize)
-	defer theBufPool.Put(buf)
-	n, err := p.read(*buf)
+	if p.bufferedSamples() >= p.bufferSizeInSamples() {
+		return 0
+	}
 
+	n, err := p.read()
 	if err != nil && err != io.EOF {
 		p.setErrorImpl(err)
 		return 0
 	}
 
-	if p.buf == nil {
-		p.buf = (*getBufferFromPool(p.bufferSize))[:0]
-	}
-
-	p.buf = append(p.buf, (*buf)[:n]...)
 	if err == io.EOF {
-		p.eof = true
-		if len(p.buf) == 0 {
-			p.state = playerPaused
+		p.eof.Store(true)
+		if p.bufferedSamples() == 0 {
+			p.finish()
 		}
 	}
 	return n
 }
 
+// setErrorImpl sets the error and closes the player.
+//
+// When setErrorImpl is called, the mutex m must be locked.
 func (p *playerImpl) setErrorImpl(err error) {
 	p.err = err
 	p.closeImpl()
 }
 
+// returnBufferToPool returns the ring buffer to the pool.
+//
+// When returnBufferToPool is called, the mutex m must be locked.
 func (p *playerImpl) returnBufferToPool() {
-	if p.buf != nil {
-		buf := p.buf
-		theBufPool.Put(&buf)
-		p.buf = nil
+	p.renderM.Lock()
+	defer p.renderM.Unlock()
+
+	if p.ring != nil {
+		ring := p.ring
+		theSamplesPool.Put(&ring)
+		p.ring = nil
+		p.head.Store(0)
+		p.tail.Store(0)
 	}
 }
 
@@ -580,9 +1370,9 @@ func (p *playerImpl) returnBufferToPool() {
 
 // defaultBufferSize returns the default size of the buffer for the audio source.
 // This buffer is used when unreading on pausing the player.
-func (m *Mux) defaultBufferSize() int {
-	bytesPerSample := m.channelCount * m.format.ByteLength()
-	s := m.sampleRate * bytesPerSample / 2 // 0.5[s]
+func (p *playerImpl) defaultBufferSize() int {
+	bytesPerSample := p.channelCount * p.format.ByteLength()
+	s := p.sampleRate * bytesPerSample / 2 // 0.5[s]
 	// Align s in multiples of bytes per sample, or a buffer could have extra bytes.
 	return s / bytesPerSample * bytesPerSample
 }
@@ -0,0 +1,1368 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// constantReader is an endless source of 0.5 in signed 16 bits.
+type constantReader struct{}
+
+func (constantReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		if i%2 == 0 {
+			buf[i] = 0
+		} else {
+			buf[i] = 0x40
+		}
+	}
+	return len(buf) / 2 * 2, nil
+}
+
+// blockingReader is a source whose Read blocks until release is closed, except for the first call.
+type blockingReader struct {
+	count   atomic.Int32
+	release chan struct{}
+}
+
+func (b *blockingReader) Read(buf []byte) (int, error) {
+	if b.count.Add(1) > 1 {
+		<-b.release
+	}
+	return len(buf), nil
+}
+
+func TestReadFloat32sAllocs(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+	// Fill the whole sources at Play so that only the render path runs during the measurement.
+	const size = 1 << 18
+	src := make([]byte, size)
+	for i := 1; i < len(src); i += 2 {
+		src[i] = 0x40 // 0.5 in signed 16 bits.
+	}
+	var players []*mux.Player
+	for range 4 {
+		p := m.NewPlayer(bytes.NewReader(src))
+		p.SetBufferSize(2 * size)
+		p.Play()
+		players = append(players, p)
+	}
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			buf[i] = 0.5
+		}
+	})
+	p.Play()
+	players = append(players, p)
+	p = m.NewPlayerFromSoundBuffer(mustNewConstantSoundBuffer(t, m, 0.5), &mux.PlayerOptions{
+		Volume: 1,
+		Loop:   true,
+		Bus:    m.NewBus(),
+	})
+	p.Play()
+	players = append(players, p)
+
+	// Play works asynchronously, but calls of Play for one player are serialized.
+	// Call Play again to wait for the first Play to finish.
+	for _, p := range players {
+		p.Play()
+	}
+
+	buf := make([]float32, 1024)
+	// Warm up the buffers for the render path.
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(3); got != want {
+		t.Fatalf("buf[0]: got: %v, want: %v", got, want)
+	}
+
+	checkReadFloat32sAllocs(t, m, buf)
+
+	for _, p := range players {
+		_ = p.Close()
+	}
+}
+
+// checkReadFloat32sAllocs checks that ReadFloat32s doesn't allocate memory.
+// The render path must be warmed up with buf before calling checkReadFloat32sAllocs.
+func checkReadFloat32sAllocs(t *testing.T, m *mux.Mux, buf []float32) {
+	t.Helper()
+	if got := testing.AllocsPerRun(100, func() {
+		m.ReadFloat32s(buf)
+	}); got != 0 {
+		t.Errorf("allocs: got: %v, want: 0", got)
+	}
+}
+
+func TestReadFloat32sDoesNotBlock(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+	src := &blockingReader{release: make(chan struct{})}
+	p := m.NewPlayer(src)
+	p.Play()
+
+	// The source is blocked. The loop and Seek will wait for the source, but the render path must not.
+	seekDone := make(chan struct{})
+	go func() {
+		defer close(seekDone)
+		_, _ = p.Seek(0, 0)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]float32, 1024)
+		for range 100 {
+			m.ReadFloat32s(buf)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ReadFloat32s blocked")
+	}
+	close(src.release)
+	_ = p.Close()
+	<-seekDone
+}
+
+func TestSlowSourceDoesNotStarveOthers(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+	slow := &blockingReader{release: make(chan struct{})}
+	p0 := m.NewPlayer(slow)
+	p0.Play()
+
+	p1 := m.NewPlayer(constantReader{})
+	p1.SetBufferSize(4096)
+	p1.Play()
+
+	// The player p1's buffer is much smaller than the data rendered here.
+	// p1 keeps playing only if its source is read while p0's source is blocked.
+	buf := make([]float32, 1024)
+	var count int
+	deadline := time.Now().Add(5 * time.Second)
+	for count < 20 {
+		if time.Now().After(deadline) {
+			t.Fatalf("player was starved: got %d full buffers", count)
+		}
+		m.ReadFloat32s(buf)
+		if buf[len(buf)-1] == 0.5 {
+			count++
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(slow.release)
+	_ = p0.Close()
+	_ = p1.Close()
+}
+
+func TestPlayerWithOptionsConvertsSource(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+
+	// A mono source with half the sample rate.
+	const frames = 4800
+	src := make([]byte, frames*2)
+	for i := 1; i < len(src); i += 2 {
+		src[i] = 0x40 // 0.5 in signed 16 bits.
+	}
+	p := m.NewPlayerWithOptions(bytes.NewReader(src), &mux.PlayerOptions{
+		SampleRate:   24000,
+		ChannelCount: 1,
+		Format:       mux.FormatSignedInt16LE,
+		Volume:       1,
+	})
+	p.Play()
+	// Play again to wait for the first Play to finish.
+	p.Play()
+
+	var rendered int
+	buf := make([]float32, 1024)
+	for p.IsPlaying() {
+		m.ReadFloat32s(buf)
+		for i := 0; i < len(buf); i += 2 {
+			if buf[i] == 0 {
+				continue
+			}
+			if buf[i] != 0.5 || buf[i+1] != 0.5 {
+				t.Fatalf("frame: got: (%v, %v), want: (0.5, 0.5)", buf[i], buf[i+1])
+			}
+			rendered++
+		}
+	}
+	if got, want := rendered, frames*2; got < want-2 || got > want+2 {
+		t.Errorf("rendered frames: got: %d, want: %d", got, want)
+	}
+}
+
+func TestSmallBufferWithResampling(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+
+	const frames = 441
+	src := make([]byte, frames*2)
+	for i := 1; i < len(src); i += 2 {
+		src[i] = 0x40 // 0.5 in signed 16 bits.
+	}
+	p := m.NewPlayerWithOptions(bytes.NewReader(src), &mux.PlayerOptions{
+		SampleRate:   44100,
+		ChannelCount: 1,
+		Format:       mux.FormatSignedInt16LE,
+		Volume:       1,
+	})
+	// The buffer is smaller than the output of one source frame.
+	p.SetBufferSize(2)
+	p.Play()
+	// Play again to wait for the first Play to finish.
+	p.Play()
+
+	var rendered int
+	buf := make([]float32, 2)
+	for i := 0; p.IsPlaying(); i++ {
+		if i >= 10*frames {
+			t.Fatalf("the player doesn't finish: rendered frames: %d", rendered)
+		}
+		m.FillBuffers()
+		m.ReadFloat32s(buf)
+		if buf[0] != 0 {
+			rendered++
+		}
+	}
+	if got, want := rendered, 480; got < want-2 || got > want+2 {
+		t.Errorf("rendered frames: got: %d, want: %d", got, want)
+	}
+}
+
+func TestPlayerWithOptionsLoop(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+	src := make([]byte, 400)
+	for i := 1; i < len(src); i += 2 {
+		src[i] = 0x40 // 0.5 in signed 16 bits.
+	}
+	p := m.NewPlayerWithOptions(bytes.NewReader(src), &mux.PlayerOptions{
+		SampleRate:   48000,
+		ChannelCount: 2,
+		Format:       mux.FormatSignedInt16LE,
+		Volume:       1,
+		Loop:         true,
+	})
+	p.Play()
+	p.Play()
+
+	// The source is much shorter than the rendered data, but the player keeps playing.
+	buf := make([]float32, 1024)
+	for range 10 {
+		m.ReadFloat32s(buf)
+		if !p.IsPlaying() {
+			t.Fatal("the looping player stopped")
+		}
+		// Wait for the source to be read.
+		time.Sleep(time.Millisecond)
+	}
+	_ = p.Close()
+}
+
+func TestZeroVolumePlayerAdvances(t *testing.T) {
+	m := mux.New(48000, 1, mux.FormatFloat32LE)
+
+	// A ramp source whose samples tell their own positions.
+	const frames = 48000
+	src := make([]byte, frames*4)
+	for i := range frames {
+		binary.LittleEndian.PutUint32(src[4*i:], math.Float32bits(float32(i)/frames))
+	}
+	p := m.NewPlayer(bytes.NewReader(src))
+	p.SetVolume(0)
+	p.Play()
+
+	buf := make([]float32, 256)
+	for range 4 {
+		m.FillBuffers()
+		m.ReadFloat32s(buf)
+		for i, v := range buf {
+			if v != 0 {
+				t.Fatalf("buf[%d] at volume 0: got: %v, want: 0", i, v)
+			}
+		}
+	}
+
+	// The volume is ramped in the next buffer.
+	p.SetVolume(1)
+	m.FillBuffers()
+	m.ReadFloat32s(buf)
+	m.FillBuffers()
+	m.ReadFloat32s(buf)
+
+	// The player must resume where it would have been without the silence.
+	for i, v := range buf {
+		if want := float32(5*len(buf)+i) / frames; v != want {
+			t.Fatalf("buf[%d]: got: %v, want: %v", i, v, want)
+		}
+	}
+	_ = p.Close()
+}
+
+// rampSource is a SampleSource whose samples are their own positions divided by n, and which ends after n samples.
+type rampSource struct {
+	pos int
+	n   int
+}
+
+func (r *rampSource) ReadSamples(buf []float32) (int, error) {
+	if r.pos >= r.n {
+		return 0, io.EOF
+	}
+	m := min(len(buf), r.n-r.pos)
+	for i := range m {
+		buf[i] = float32(r.pos+i) / float32(r.n)
+	}
+	r.pos += m
+	return m, nil
+}
+
+// Read is never called as the player prefers ReadSamples.
+func (r *rampSource) Read(buf []byte) (int, error) {
+	return 0, fmt.Errorf("Read must not be called")
+}
+
+func TestVirtualVoices(t *testing.T) {
+	const frames = 48000
+	src := make([]byte, frames*2*4)
+	for i := range frames * 2 {
+		binary.LittleEndian.PutUint32(src[4*i:], math.Float32bits(float32(i)/(frames*2)))
+	}
+
+	testCases := []struct {
+		name    string
+		spatial bool
+		muteMux bool
+		muteBus bool
+	}{
+		{name: "player volume"},
+		{name: "player volume, spatial", spatial: true},
+		{name: "mux muted", muteMux: true},
+		{name: "mux muted, spatial", spatial: true, muteMux: true},
+		{name: "bus muted", muteBus: true},
+		{name: "bus muted, spatial", spatial: true, muteBus: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// ref is always audible, and m is silent for the first buffers.
+			ref := mux.New(48000, 2, mux.FormatFloat32LE)
+			m := mux.New(48000, 2, mux.FormatFloat32LE)
+			refP := ref.NewPlayer(bytes.NewReader(src))
+			var bus *mux.Bus
+			if tc.muteBus {
+				bus = m.NewBus()
+			}
+			p := m.NewPlayerWithOptions(bytes.NewReader(src), &mux.PlayerOptions{
+				SampleRate:   48000,
+				ChannelCount: 2,
+				Format:       mux.FormatFloat32LE,
+				Volume:       1,
+				Bus:          bus,
+			})
+			if tc.spatial {
+				refP.SetEmitter(&mux.Emitter{Position: mux.Vector{X: 1}})
+				p.SetEmitter(&mux.Emitter{Position: mux.Vector{X: 1}})
+			}
+			switch {
+			case tc.muteMux:
+				m.SetMuted(true)
+				m.ReadFloat32s(make([]float32, 2))
+			case tc.muteBus:
+				bus.SetMuted(true)
+				m.ReadFloat32s(make([]float32, 2))
+			default:
+				p.SetVolume(0)
+			}
+			refP.Play()
+			p.Play()
+
+			refBuf := make([]float32, 512)
+			buf := make([]float32, 512)
+			for i := range 6 {
+				if i == 4 {
+					// The volume is ramped in this buffer.
+					switch {
+					case tc.muteMux:
+						m.SetMuted(false)
+					case tc.muteBus:
+						bus.SetMuted(false)
+					default:
+						p.SetVolume(1)
+					}
+				}
+				ref.FillBuffers()
+				ref.ReadFloat32s(refBuf)
+				m.FillBuffers()
+				m.ReadFloat32s(buf)
+				if i < 4 {
+					for j, v := range buf {
+						if v != 0 {
+							t.Fatalf("buffer %d: buf[%d]: got: %v, want: 0", i, j, v)
+						}
+					}
+				}
+			}
+
+			// The virtual voice resumes exactly where the audible voice is.
+			for j := range buf {
+				if buf[j] != refBuf[j] {
+					t.Fatalf("buf[%d]: got: %v, want: %v", j, buf[j], refBuf[j])
+				}
+			}
+			_ = refP.Close()
+			_ = p.Close()
+		})
+	}
+}
+
+func TestSampleSource(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+	for _, newPlayer := range []func(src *rampSource) *mux.Player{
+		func(src *rampSource) *mux.Player {
+			return m.NewPlayerFromSampleSource(src)
+		},
+		// A SampleSource given to NewPlayer is read by ReadSamples regardless of the mux's format.
+		func(src *rampSource) *mux.Player {
+			return m.NewPlayer(src)
+		},
+	} {
+		const n = 1000
+		p := newPlayer(&rampSource{n: n})
+		p.Play()
+
+		var got []float32
+		buf := make([]float32, 256)
+		for p.IsPlaying() {
+			m.FillBuffers()
+			m.ReadFloat32s(buf)
+			got = append(got, buf...)
+		}
+		if err := p.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) < n {
+			t.Fatalf("len(got): got: %d, want: >= %d", len(got), n)
+		}
+		for i, v := range got[:n] {
+			if want := float32(i) / n; v != want {
+				t.Fatalf("got[%d]: got: %v, want: %v", i, v, want)
+			}
+		}
+		for i, v := range got[n:] {
+			if v != 0 {
+				t.Fatalf("got[%d] after the end: got: %v, want: 0", i+n, v)
+			}
+		}
+	}
+}
+
+func TestRenderFunc(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+	var calls int
+	var pos int
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		calls++
+		for i := range buf {
+			if buf[i] != 0 {
+				t.Errorf("buf[%d] is not cleared: %v", i, buf[i])
+			}
+			buf[i] = float32(pos+i) / 1024
+		}
+		pos += len(buf)
+	})
+
+	// render is not called while the player is paused.
+	buf := make([]float32, 256)
+	m.ReadFloat32s(buf)
+	if calls != 0 {
+		t.Errorf("render calls before Play: got: %d, want: 0", calls)
+	}
+
+	p.Play()
+	p.Play()
+	m.ReadFloat32s(buf)
+	for i, v := range buf {
+		if want := float32(i) / 1024; v != want {
+			t.Fatalf("buf[%d]: got: %v, want: %v", i, v, want)
+		}
+	}
+
+	// render is still called at volume 0 so that the render function's state advances.
+	p.SetVolume(0)
+	m.ReadFloat32s(buf)
+	m.ReadFloat32s(buf)
+	for i, v := range buf {
+		if v != 0 {
+			t.Fatalf("buf[%d] at volume 0: got: %v, want: 0", i, v)
+		}
+	}
+	if got, want := calls, 3; got != want {
+		t.Errorf("render calls: got: %d, want: %d", got, want)
+	}
+
+	p.SetVolume(1)
+	m.ReadFloat32s(buf)
+	m.ReadFloat32s(buf)
+	for i, v := range buf {
+		if want := float32(4*len(buf)+i) / 1024; v != want {
+			t.Fatalf("buf[%d] after volume 0: got: %v, want: %v", i, v, want)
+		}
+	}
+
+	// The player never finishes by itself.
+	if !p.IsPlaying() {
+		t.Errorf("IsPlaying(): got: false, want: true")
+	}
+	_ = p.Close()
+}
+
+func TestSoundBufferSharedByPlayers(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+	const frames = 1000
+	src := make([]byte, frames*4)
+	for i := 1; i < len(src); i += 2 {
+		src[i] = 0x40 // 0.5 in signed 16 bits.
+	}
+	b, err := m.NewSoundBuffer(bytes.NewReader(src), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p0 := m.NewPlayerFromSoundBuffer(b, &mux.PlayerOptions{Volume: 1})
+	p1 := m.NewPlayerFromSoundBuffer(b, &mux.PlayerOptions{Volume: 1})
+	p0.Play()
+	p1.Play()
+	p0.Play()
+	p1.Play()
+
+	// Both players play the whole buffer at once.
+	var rendered int
+	buf := make([]float32, 256)
+	for p0.IsPlaying() || p1.IsPlaying() {
+		m.ReadFloat32s(buf)
+		for i := 0; i < len(buf); i += 2 {
+			if buf[i] == 0 {
+				continue
+			}
+			if buf[i] != 1 || buf[i+1] != 1 {
+				t.Fatalf("frame: got: (%v, %v), want: (1, 1)", buf[i], buf[i+1])
+			}
+			rendered++
+		}
+	}
+	if got, want := rendered, frames; got != want {
+		t.Errorf("rendered frames: got: %d, want: %d", got, want)
+	}
+
+	// A player can rewind the buffer independently.
+	if _, err := p0.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	p0.Play()
+	p0.Play()
+	m.ReadFloat32s(buf)
+	if buf[0] != 0.5 {
+		t.Errorf("after seeking: got: %v, want: 0.5", buf[0])
+	}
+}
+
+func TestPlayOnceIsNotCollected(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+	const frames = 4800
+	src := make([]byte, frames*4)
+	for i := 1; i < len(src); i += 2 {
+		src[i] = 0x40 // 0.5 in signed 16 bits.
+	}
+	// The player is not referenced after PlayOnce.
+	m.NewPlayer(bytes.NewReader(src)).PlayOnce()
+
+	var rendered int
+	buf := make([]float32, 256)
+	for range 1000 {
+		runtime.GC()
+		m.ReadFloat32s(buf)
+		for i := 0; i < len(buf); i += 2 {
+			if buf[i] != 0 {
+				rendered++
+			}
+		}
+		if rendered == frames {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := rendered, frames; got != want {
+		t.Errorf("rendered frames: got: %d, want: %d", got, want)
+	}
+}
+
+func TestMasterVolume(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			buf[i] = 0.5
+		}
+	})
+	p.Play()
+	p.Play()
+
+	buf := make([]float32, 256)
+	m.ReadFloat32s(buf)
+	if got, want := buf[len(buf)-1], float32(0.5); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	// The volume is ramped in the next buffer.
+	m.SetVolume(2)
+	m.ReadFloat32s(buf)
+	if got := buf[0]; got != 0.5 {
+		t.Errorf("first sample after SetVolume: got: %v, want: 0.5", got)
+	}
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(1); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	m.SetMuted(true)
+	m.ReadFloat32s(buf)
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(0); got != want {
+		t.Errorf("muted: got: %v, want: %v", got, want)
+	}
+	if got, want := m.Volume(), 2.0; got != want {
+		t.Errorf("volume while muted: got: %v, want: %v", got, want)
+	}
+
+	m.SetMuted(false)
+	m.SetVolume(100)
+	if got, want := m.Volume(), float64(mux.MaxVolume); got != want {
+		t.Errorf("clamped volume: got: %v, want: %v", got, want)
+	}
+}
+
+func TestBus(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+	constant := func(buf []float32) {
+		for i := range buf {
+			buf[i] = 0.5
+		}
+	}
+	bus := m.NewBus()
+	// p0 is mixed into the output directly, and p1 is mixed into the bus.
+	p0 := m.NewPlayerFromRenderFunc(constant)
+	p1 := m.NewPlayerFromSoundBuffer(mustNewConstantSoundBuffer(t, m, 0.5), &mux.PlayerOptions{Bus: bus, Volume: 1, Loop: true})
+	p0.Play()
+	p1.Play()
+	// Play again to wait for the first Play to finish.
+	p0.Play()
+	p1.Play()
+
+	buf := make([]float32, 256)
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(1); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	// The bus's volume is ramped in the next buffer, and applied only to the bus's player.
+	bus.SetVolume(0.5)
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(1); got != want {
+		t.Errorf("first sample after SetVolume: got: %v, want: %v", got, want)
+	}
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(0.75); got != want {
+		t.Errorf("bus volume 0.5: got: %v, want: %v", got, want)
+	}
+
+	// The master volume is applied after the bus's volume.
+	m.SetVolume(2)
+	m.ReadFloat32s(buf)
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(1.5); got != want {
+		t.Errorf("master volume 2: got: %v, want: %v", got, want)
+	}
+
+	bus.SetMuted(true)
+	m.ReadFloat32s(buf)
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(1); got != want {
+		t.Errorf("bus muted: got: %v, want: %v", got, want)
+	}
+	if got, want := bus.Volume(), 0.5; got != want {
+		t.Errorf("bus volume while muted: got: %v, want: %v", got, want)
+	}
+	bus.SetMuted(false)
+	m.ReadFloat32s(buf)
+	m.ReadFloat32s(buf)
+	if got, want := buf[0], float32(1.5); got != want {
+		t.Errorf("bus unmuted: got: %v, want: %v", got, want)
+	}
+
+	bus.SetVolume(100)
+	if got, want := bus.Volume(), float64(mux.MaxVolume); got != want {
+		t.Errorf("clamped bus volume: got: %v, want: %v", got, want)
+	}
+	_ = p0.Close()
+	_ = p1.Close()
+}
+
+// mustNewConstantSoundBuffer returns a sound buffer of 1 second of v in the mux's channels.
+func mustNewConstantSoundBuffer(t *testing.T, m *mux.Mux, v float32) *mux.SoundBuffer {
+	t.Helper()
+	src := make([]byte, 48000*2*4)
+	for i := 0; i < len(src); i += 4 {
+		binary.LittleEndian.PutUint32(src[i:], math.Float32bits(v))
+	}
+	b, err := m.NewSoundBuffer(bytes.NewReader(src), &mux.PlayerOptions{
+		SampleRate:   48000,
+		ChannelCount: 2,
+		Format:       mux.FormatFloat32LE,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestChannelMatrix(t *testing.T) {
+	const h = math.Sqrt2 / 2
+	testCases := []struct {
+		name     string
+		srcCount int
+		srcMask  mux.ChannelMask
+		dstCount int
+		dstMask  mux.ChannelMask
+		want     []float32
+	}{
+		{
+			name:     "stereo to stereo",
+			srcCount: 2,
+			srcMask:  mux.ChannelMaskStereo,
+			dstCount: 2,
+			dstMask:  mux.ChannelMaskStereo,
+			want: []float32{
+				1, 0,
+				0, 1,
+			},
+		},
+		{
+			name:     "5.1 to stereo",
+			srcCount: 6,
+			srcMask:  mux.ChannelMaskSurround51,
+			dstCount: 2,
+			dstMask:  mux.ChannelMaskStereo,
+			want: []float32{
+				// FL, FR, FC, LFE, BL, BR
+				1, 0, h, 0, h, 0,
+				0, 1, h, 0, 0, h,
+			},
+		},
+		{
+			name:     "7.1 to 5.1",
+			srcCount: 8,
+			srcMask:  mux.ChannelMaskSurround71,
+			dstCount: 6,
+			dstMask:  mux.ChannelMaskSurround51,
+			want: []float32{
+				// FL, FR, FC, LFE, BL, BR, SL, SR
+				1, 0, 0, 0, 0, 0, 0, 0,
+				0, 1, 0, 0, 0, 0, 0, 0,
+				0, 0, 1, 0, 0, 0, 0, 0,
+				0, 0, 0, 1, 0, 0, 0, 0,
+				0, 0, 0, 0, 1, 0, 1, 0,
+				0, 0, 0, 0, 0, 1, 0, 1,
+			},
+		},
+		{
+			name:     "center to 5.1",
+			srcCount: 1,
+			srcMask:  mux.ChannelFrontCenter,
+			dstCount: 6,
+			dstMask:  mux.ChannelMaskSurround51,
+			want:     []float32{0, 0, 1, 0, 0, 0},
+		},
+		{
+			name:     "unknown positions",
+			srcCount: 3,
+			srcMask:  0,
+			dstCount: 2,
+			dstMask:  0,
+			want: []float32{
+				1, 0, 0,
+				0, 1, 0,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mux.ChannelMatrix(tc.srcCount, tc.srcMask, tc.dstCount, tc.dstMask)
+			if len(got) != len(tc.want) {
+				t.Fatalf("len: got: %d, want: %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if math.Abs(float64(got[i]-tc.want[i])) > 1e-6 {
+					t.Errorf("matrix: got: %v, want: %v", got, tc.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestOutputDownmix(t *testing.T) {
+	m := mux.NewWithChannelMask(48000, 6, mux.FormatFloat32LE, mux.ChannelMaskSurround51)
+	m.SetOutputChannels(2, mux.ChannelMaskStereo)
+
+	// A render function writing only the front left channel.
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := 0; i < len(buf); i += 6 {
+			buf[i] = 0.5
+		}
+	})
+	p.Play()
+	p.Play()
+
+	buf := make([]float32, 256)
+	m.ReadFloat32s(buf)
+	for i := 0; i < len(buf); i += 2 {
+		if buf[i] != 0.5 || buf[i+1] != 0 {
+			t.Fatalf("frame: got: (%v, %v), want: (0.5, 0)", buf[i], buf[i+1])
+		}
+	}
+}
+
+func TestSpatialPanningAndAttenuation(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			buf[i] = 0.5
+		}
+	})
+	// The emitter is at the right of the listener facing -Z.
+	p.SetEmitter(&mux.Emitter{
+		Position: mux.Vector{X: 2},
+	})
+	p.Play()
+	p.Play()
+
+	buf := make([]float32, 1024)
+	// The first sample is delayed for the Doppler effect. Check the last frame.
+	m.ReadFloat32s(buf)
+	l, r := buf[len(buf)-2], buf[len(buf)-1]
+	if math.Abs(float64(l)) > 1e-6 {
+		t.Errorf("left: got: %v, want: 0", l)
+	}
+	// The inverse attenuation at distance 2 with RefDistance 1 is 0.5.
+	if math.Abs(float64(r)-0.25) > 1e-6 {
+		t.Errorf("right: got: %v, want: 0.25", r)
+	}
+
+	checkReadFloat32sAllocs(t, m, buf)
+}
+
+func TestSpatialDoppler(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+
+	var consumed int
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		consumed += len(buf) / 2
+	})
+	// The emitter approaches the listener at half the speed of sound, which doubles the pitch.
+	p.SetEmitter(&mux.Emitter{
+		Position:      mux.Vector{Z: -10},
+		Velocity:      mux.Vector{Z: 343.3 / 2},
+		DopplerFactor: 1,
+	})
+	p.Play()
+	p.Play()
+
+	buf := make([]float32, 1024)
+	for range 10 {
+		m.ReadFloat32s(buf)
+	}
+	if got, want := consumed, 10*len(buf)/2*2; got < want-1 || got > want+1 {
+		t.Errorf("consumed frames: got: %d, want: %d", got, want)
+	}
+}
+
+func TestSpatialHRTF(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	m.SetHRTF(mux.DefaultHRTF(48000))
+
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			buf[i] = float32(i%7) / 7
+		}
+	})
+	// The emitter is at the left of the listener.
+	p.SetEmitter(&mux.Emitter{
+		Position: mux.Vector{X: -1},
+	})
+	p.Play()
+	p.Play()
+
+	buf := make([]float32, 1024)
+	m.ReadFloat32s(buf)
+	m.ReadFloat32s(buf)
+	var l, r float64
+	for i := 0; i < len(buf); i += 2 {
+		l += float64(buf[i] * buf[i])
+		r += float64(buf[i+1] * buf[i+1])
+	}
+	if l <= r*2 {
+		t.Errorf("energy: left: %v, right: %v, want: left > right*2", l, r)
+	}
+
+	checkReadFloat32sAllocs(t, m, buf)
+}
+
+func TestLoadHRTF(t *testing.T) {
+	const data = `{
+  "Data.SamplingRate": 44100,
+  "SourcePosition": [[0, 0, 1], [90, 0, 1]],
+  "Data.IR": [[[1, 0], [1, 0]], [[1, 0.5], [0.25, 0]]]
+}`
+	h, err := mux.LoadHRTF(bytes.NewReader([]byte(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.SampleRate, 44100; got != want {
+		t.Errorf("sample rate: got: %d, want: %d", got, want)
+	}
+	if got, want := len(h.HRIRs), 2; got != want {
+		t.Fatalf("HRIRs: got: %d, want: %d", got, want)
+	}
+	if got, want := h.HRIRs[1].Azimuth, 90.0; got != want {
+		t.Errorf("azimuth: got: %v, want: %v", got, want)
+	}
+	if got, want := h.HRIRs[1].Right[0], float32(0.25); got != want {
+		t.Errorf("right: got: %v, want: %v", got, want)
+	}
+
+	if _, err := mux.LoadHRTF(bytes.NewReader([]byte(`{"Data.SamplingRate": 44100, "SourcePosition": [[0, 0, 1]], "Data.IR": []}`))); err == nil {
+		t.Error("LoadHRTF must fail for a mismatched file")
+	}
+}
+
+func TestMeter(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			if i%2 == 0 {
+				buf[i] = 0.5
+			} else {
+				buf[i] = -0.5
+			}
+		}
+	})
+	p.SetVolume(3)
+	p.Play()
+	p.Play()
+	m.SetMeterDecay(50 * time.Millisecond)
+
+	buf := make([]float32, 1024)
+	for range 100 {
+		m.ReadFloat32s(buf)
+	}
+
+	pm := p.Meter()
+	if got, want := pm.Peak, 1.5; got != want {
+		t.Errorf("player peak: got: %v, want: %v", got, want)
+	}
+	if got, want := pm.RMS, 1.5; math.Abs(got-want) > 1e-3 {
+		t.Errorf("player RMS: got: %v, want: %v", got, want)
+	}
+	if got, want := pm.Clips, int64(100*len(buf)); got != want {
+		t.Errorf("player clips: got: %v, want: %v", got, want)
+	}
+	if got, want := m.Meter().Peak, 1.5; got != want {
+		t.Errorf("master peak: got: %v, want: %v", got, want)
+	}
+
+	// The meters decay after the player stops.
+	p.Pause()
+	for range 100 {
+		m.ReadFloat32s(buf)
+	}
+	if got := p.Meter().Peak; got > 0.01 {
+		t.Errorf("player peak after pausing: got: %v, want: < 0.01", got)
+	}
+	if got := m.Meter().RMS; got > 0.01 {
+		t.Errorf("master RMS after pausing: got: %v, want: < 0.01", got)
+	}
+
+	checkReadFloat32sAllocs(t, m, buf)
+}
+
+func TestBusMeter(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	m.SetMeterDecay(50 * time.Millisecond)
+	bus := m.NewBus()
+	bus.SetVolume(3)
+	p := m.NewPlayerFromSoundBuffer(mustNewConstantSoundBuffer(t, m, 0.5), &mux.PlayerOptions{
+		Volume: 1,
+		Loop:   true,
+		Bus:    bus,
+	})
+	p.Play()
+	p.Play()
+
+	buf := make([]float32, 1024)
+	for range 100 {
+		m.ReadFloat32s(buf)
+	}
+
+	bm := bus.Meter()
+	if got, want := bm.Peak, 1.5; got != want {
+		t.Errorf("bus peak: got: %v, want: %v", got, want)
+	}
+	if got, want := bm.RMS, 1.5; math.Abs(got-want) > 1e-3 {
+		t.Errorf("bus RMS: got: %v, want: %v", got, want)
+	}
+	if got, want := bm.Clips, int64(100*len(buf)); got != want {
+		t.Errorf("bus clips: got: %v, want: %v", got, want)
+	}
+	// The player's meter is before the bus's volume.
+	if got, want := p.Meter().Peak, 0.5; got != want {
+		t.Errorf("player peak: got: %v, want: %v", got, want)
+	}
+
+	// The meter decays while the bus is muted.
+	bus.SetMuted(true)
+	for range 100 {
+		m.ReadFloat32s(buf)
+	}
+	if got := bus.Meter().Peak; got > 0.01 {
+		t.Errorf("bus peak after muting: got: %v, want: < 0.01", got)
+	}
+
+	checkReadFloat32sAllocs(t, m, buf)
+	_ = p.Close()
+}
+
+func TestAnalyzer(t *testing.T) {
+	const (
+		sampleRate = 48000
+		size       = 1024
+		bin        = 32
+	)
+	m := mux.New(sampleRate, 2, mux.FormatFloat32LE)
+	var phase float64
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := 0; i < len(buf); i += 2 {
+			v := float32(0.5 * math.Sin(phase))
+			buf[i] = v
+			buf[i+1] = v
+			phase += 2 * math.Pi * bin / size
+		}
+	})
+	p.SetVolume(0.5)
+	p.Play()
+
+	pa, err := mux.NewAnalyzer(size, sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ma, err := mux.NewAnalyzer(size, sampleRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetAnalyzer(pa)
+	m.SetAnalyzer(ma)
+
+	buf := make([]float32, 512)
+	for range 10 {
+		m.ReadFloat32s(buf)
+	}
+
+	for _, tc := range []struct {
+		name string
+		a    *mux.Analyzer
+	}{
+		{name: "player", a: pa},
+		{name: "master", a: ma},
+	} {
+		spectrum := tc.a.Spectrum(nil)
+		if got, want := len(spectrum), size/2+1; got != want {
+			t.Fatalf("%s: len(spectrum): got: %d, want: %d", tc.name, got, want)
+		}
+		var peak int
+		for k, v := range spectrum {
+			if v > spectrum[peak] {
+				peak = k
+			}
+		}
+		if peak != bin {
+			t.Errorf("%s: peak bin: got: %d, want: %d", tc.name, peak, bin)
+		}
+		if got, want := float64(spectrum[bin]), 0.25; math.Abs(got-want) > 1e-3 {
+			t.Errorf("%s: magnitude: got: %v, want: %v", tc.name, got, want)
+		}
+
+		waveform := tc.a.Waveform(nil)
+		if got, want := len(waveform), size; got != want {
+			t.Fatalf("%s: len(waveform): got: %d, want: %d", tc.name, got, want)
+		}
+
+		// The band including 1500 Hz is the loudest.
+		bands := tc.a.LogSpectrum(nil, 10, 20, 20000)
+		var peakBand int
+		for i, v := range bands {
+			if v > bands[peakBand] {
+				peakBand = i
+			}
+		}
+		if got, want := peakBand, int(10*math.Log(1500.0/20)/math.Log(1000)); got != want {
+			t.Errorf("%s: peak band: got: %d, want: %d", tc.name, got, want)
+		}
+	}
+
+	if _, err := mux.NewAnalyzer(1000, sampleRate); err == nil {
+		t.Errorf("NewAnalyzer with a non-power-of-two size must fail")
+	}
+
+	checkReadFloat32sAllocs(t, m, buf)
+}
+
+// blockingWriter blocks until unblock is closed.
+type blockingWriter struct {
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(buf []byte) (int, error) {
+	<-w.unblock
+	return len(buf), nil
+}
+
+func TestOutputTap(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			buf[i] = 0.5
+		}
+	})
+	p.Play()
+
+	var out bytes.Buffer
+	tap := m.AddOutputTap(&out, mux.FormatSignedInt16LE)
+	if got, want := tap.ChannelCount(), 2; got != want {
+		t.Errorf("ChannelCount(): got: %d, want: %d", got, want)
+	}
+
+	buf := make([]float32, 1024)
+	for range 10 {
+		m.ReadFloat32s(buf)
+	}
+	if err := tap.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Rendering after closing the tap is not captured.
+	m.ReadFloat32s(buf)
+
+	if got, want := out.Len(), 10*len(buf)*2; got != want {
+		t.Fatalf("captured bytes: got: %d, want: %d", got, want)
+	}
+	bs := out.Bytes()
+	for i := 0; i < len(bs); i += 2 {
+		if got, want := int16(bs[i])|int16(bs[i+1])<<8, int16(1<<14); got != want {
+			t.Fatalf("sample %d: got: %d, want: %d", i/2, got, want)
+		}
+	}
+	if got := tap.DroppedBlocks(); got != 0 {
+		t.Errorf("DroppedBlocks(): got: %d, want: 0", got)
+	}
+}
+
+func TestOutputTapLargeBlocks(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	var counter float32
+	p := m.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			buf[i] = counter
+			counter++
+		}
+	})
+	p.Play()
+
+	var out bytes.Buffer
+	tap := m.AddOutputTap(&out, mux.FormatFloat32LE)
+
+	// The blocks of 1 second are larger than the initial buffer of the tap.
+	sizes := []int{1024, 48000 * 2, 48000 * 2}
+	var total int
+	for _, size := range sizes {
+		m.ReadFloat32s(make([]float32, size))
+		total += size
+	}
+	if err := tap.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := tap.DroppedBlocks(); got != 0 {
+		t.Errorf("DroppedBlocks(): got: %d, want: 0", got)
+	}
+	if got, want := out.Len(), total*4; got != want {
+		t.Fatalf("captured bytes: got: %d, want: %d", got, want)
+	}
+	// The samples are captured in order across the buffers of the tap.
+	bs := out.Bytes()
+	for i := range total {
+		if got, want := math.Float32frombits(binary.LittleEndian.Uint32(bs[4*i:])), float32(i); got != want {
+			t.Fatalf("sample %d: got: %v, want: %v", i, got, want)
+		}
+	}
+	_ = p.Close()
+}
+
+func TestOutputTapSlowWriter(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	w := &blockingWriter{unblock: make(chan struct{})}
+	tap := m.AddOutputTap(w, mux.FormatFloat32LE)
+
+	// The writer never returns, but rendering must not block.
+	buf := make([]float32, 4096)
+	for range 100 {
+		m.ReadFloat32s(buf)
+	}
+	if got := tap.DroppedBlocks(); got == 0 {
+		t.Errorf("DroppedBlocks(): got: 0, want: > 0")
+	}
+
+	checkReadFloat32sAllocs(t, m, buf)
+
+	close(w.unblock)
+	if err := tap.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutputTapError(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatFloat32LE)
+	errWrite := fmt.Errorf("write error")
+	tap := m.AddOutputTap(&errorWriter{err: errWrite}, mux.FormatFloat32LE)
+
+	buf := make([]float32, 1024)
+	m.ReadFloat32s(buf)
+	if err := tap.Close(); err != errWrite {
+		t.Errorf("Close(): got: %v, want: %v", err, errWrite)
+	}
+}
+
+// errorWriter always fails.
+type errorWriter struct {
+	err error
+}
+
+func (w *errorWriter) Write(buf []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestClose(t *testing.T) {
+	m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+	p := m.NewPlayer(constantReader{})
+	p.Play()
+	m.FillBuffers()
+
+	buf := make([]float32, 256)
+	m.ReadFloat32s(buf)
+	if buf[0] == 0 {
+		t.Fatalf("buf[0] before Close: got: 0, want: non-zero")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if p.IsPlaying() {
+		t.Errorf("IsPlaying() after Close: got: true, want: false")
+	}
+	m.ReadFloat32s(buf)
+	for i, v := range buf {
+		if v != 0 {
+			t.Fatalf("buf[%d] after Close: got: %v, want: 0", i, v)
+		}
+	}
+
+	// A player cannot play after the mux is closed.
+	p2 := m.NewPlayer(constantReader{})
+	p2.Play()
+	m.FillBuffers()
+	if p2.Err() == nil {
+		t.Errorf("Err() of a player played after Close: got: nil, want: an error")
+	}
+	if p2.IsPlaying() {
+		t.Errorf("IsPlaying() of a player played after Close: got: true, want: false")
+	}
+
+	// Close can be called more than once.
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkReadFloat32s(b *testing.B) {
+	for _, n := range []int{1, 16, 64} {
+		b.Run(fmt.Sprintf("players=%d", n), func(b *testing.B) {
+			m := mux.New(48000, 2, mux.FormatSignedInt16LE)
+
+			const size = 1 << 18
+			var players []*mux.Player
+			for range n {
+				p := m.NewPlayer(bytes.NewReader(make([]byte, size)))
+				p.SetBufferSize(2 * size)
+				p.Play()
+				players = append(players, p)
+			}
+
+			buf := make([]float32, 1024)
+			b.ReportAllocs()
+			for b.Loop() {
+				// Refill the players outside of the measurement so that the mixing is measured.
+				// Seek before the players finish so that they keep playing.
+				if players[0].BufferedSize() < len(buf)*2*2 {
+					b.StopTimer()
+					for _, p := range players {
+						_, _ = p.Seek(0, io.SeekStart)
+					}
+					b.StartTimer()
+				}
+				m.ReadFloat32s(buf)
+			}
+
+			for _, p := range players {
+				_ = p.Close()
+			}
+		})
+	}
+}
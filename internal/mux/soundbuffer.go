@@ -0,0 +1,181 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"errors"
+	"io"
+)
+
+// SoundBuffer is decoded samples in the mux's layout, shared by multiple players.
+// SoundBuffer is immutable.
+type SoundBuffer struct {
+	samples []float32
+}
+
+// NewSoundBuffer reads the whole src and creates a SoundBuffer.
+// If options is nil, src is assumed to be in the mux's format. Only the format fields of options are used.
+//
+// If src implements SampleSource, float32 values are read from src directly.
+func (m *Mux) NewSoundBuffer(src io.Reader, options *PlayerOptions) (*SoundBuffer, error) {
+	if options == nil {
+		options = m.defaultPlayerOptions()
+	}
+	c := m.newConverter(options)
+
+	var samples []float32
+	if s, ok := src.(SampleSource); ok {
+		buf := make([]float32, 4096)
+		for {
+			n, err := s.ReadSamples(buf)
+			samples = append(samples, buf[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		bs, err := io.ReadAll(src)
+		if err != nil {
+			return nil, err
+		}
+		samples = options.Format.appendDecoded(nil, bs[:len(bs)/options.Format.ByteLength()*options.Format.ByteLength()])
+	}
+	samples = samples[:len(samples)/options.ChannelCount*options.ChannelCount]
+
+	if !c.isIdentity() {
+		samples = c.appendConverted(nil, samples)
+	}
+	return &SoundBuffer{
+		samples: samples,
+	}, nil
+}
+
+// Len returns the number of the samples in the mux's layout.
+func (b *SoundBuffer) Len() int {
+	return len(b.samples)
+}
+
+// NewPlayerFromSoundBuffer creates a new player playing b.
+// Players created from the same SoundBuffer share its samples. Each player has only its own position.
+//
+// If options is nil, the default options are used. Only Volume, Loop and Bus of options are used.
+func (m *Mux) NewPlayerFromSoundBuffer(b *SoundBuffer, options *PlayerOptions) *Player {
+	op := m.defaultPlayerOptions()
+	if options != nil {
+		op.Volume = options.Volume
+		op.Loop = options.Loop
+		op.Bus = options.Bus
+	}
+	return m.newPlayer(&playerImpl{
+		sound: b,
+	}, op)
+}
+
+// isSoundFinished reports whether the position is at the end of the sound.
+//
+// When isSoundFinished is called, the mutex m must be locked.
+func (p *playerImpl) isSoundFinished() bool {
+	p.renderM.Lock()
+	defer p.renderM.Unlock()
+	return p.soundPos >= len(p.sound.samples)
+}
+
+func (p *playerImpl) seekSound(offset int64, whence int) (int64, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.renderM.Lock()
+	defer p.renderM.Unlock()
+
+	// The offset is in bytes of the mux's format.
+	bytesPerFrame := int64(p.mux.format.ByteLength() * p.mux.channelCount)
+	frames := int64(len(p.sound.samples) / p.mux.channelCount)
+
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = int64(p.soundPos/p.mux.channelCount)*bytesPerFrame + offset
+	case io.SeekEnd:
+		pos = frames*bytesPerFrame + offset
+	default:
+		return 0, errors.New("mux: invalid whence")
+	}
+	if pos < 0 {
+		return 0, errors.New("mux: negative position")
+	}
+
+	frame := min(pos/bytesPerFrame, frames)
+	p.soundPos = int(frame) * p.mux.channelCount
+	return pos, nil
+}
+
+// readSoundAndAdd adds the samples of the sound to buf.
+//
+// readSoundAndAdd is called on the render path. readSoundAndAdd must not allocate or wait for locks.
+func (p *playerImpl) readSoundAndAdd(buf []float32, inaudible bool) int {
+	if !p.renderM.TryLock() {
+		return 0
+	}
+	defer p.renderM.Unlock()
+
+	var mb meterBlock
+	defer p.meter.update(&mb, len(buf), p.mux.meterCoef(len(buf)))
+	analyzer := p.analyzer.Load()
+
+	if p.state.Load() != playerPlay {
+		return 0
+	}
+
+	samples := p.sound.samples
+	channelCount := p.mux.channelCount
+
+	prevVolume := float32(p.prevVolume)
+	volume := float32(p.volume.Load())
+	p.prevVolume = float64(volume)
+	virtual := inaudible || (prevVolume == 0 && volume == 0)
+
+	var n int
+	for n < len(buf) {
+		if p.soundPos >= len(samples) {
+			if !p.loop || len(samples) == 0 {
+				p.finish()
+				break
+			}
+			p.soundPos = 0
+		}
+
+		m := min(len(buf)-n, len(samples)-p.soundPos)
+		// An inaudible player is a virtual voice. Its position advances as usual, but its samples are not mixed.
+		if !virtual {
+			// Split the volume ramp of the whole buffer at the boundary of the sound.
+			v0 := prevVolume + (volume-prevVolume)*float32(n/channelCount)/float32(len(buf)/channelCount)
+			v1 := prevVolume + (volume-prevVolume)*float32((n+m)/channelCount)/float32(len(buf)/channelCount)
+			addWithVolume(buf[n:n+m], samples[p.soundPos:p.soundPos+m], v0, v1, channelCount)
+			mb.add(samples[p.soundPos:p.soundPos+m], volume)
+			analyzer.write(samples[p.soundPos:p.soundPos+m], volume, channelCount)
+		}
+		p.soundPos += m
+		n += m
+	}
+	if p.soundPos >= len(samples) && !p.loop {
+		p.finish()
+	}
+	return n
+}
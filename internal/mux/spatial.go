@@ -0,0 +1,623 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"math"
+	"sort"
+)
+
+// Vector is a 3D vector. Vector must sync with oto's Vector.
+type Vector struct {
+	X, Y, Z float64
+}
+
+func (v Vector) sub(w Vector) Vector {
+	return Vector{v.X - w.X, v.Y - w.Y, v.Z - w.Z}
+}
+
+func (v Vector) dot(w Vector) float64 {
+	return v.X*w.X + v.Y*w.Y + v.Z*w.Z
+}
+
+func (v Vector) cross(w Vector) Vector {
+	return Vector{v.Y*w.Z - v.Z*w.Y, v.Z*w.X - v.X*w.Z, v.X*w.Y - v.Y*w.X}
+}
+
+func (v Vector) length() float64 {
+	return math.Sqrt(v.dot(v))
+}
+
+func (v Vector) scale(s float64) Vector {
+	return Vector{v.X * s, v.Y * s, v.Z * s}
+}
+
+// Listener is the position, the velocity and the orientation of the listener.
+// Listener must sync with oto's Listener.
+type Listener struct {
+	Position Vector
+	Velocity Vector
+
+	// Forward and Up are the orientation. If they are zero or parallel, (0, 0, -1) and (0, 1, 0) are used.
+	Forward Vector
+	Up      Vector
+
+	// SpeedOfSound is the speed of sound in the units of positions per second. If 0, 343.3 is used.
+	SpeedOfSound float64
+}
+
+// axes returns the unit vectors of the listener's right, up and forward directions.
+func (l *Listener) axes() (right, up, forward Vector) {
+	forward, up = l.Forward, l.Up
+	right = forward.cross(up)
+	if right.length() == 0 {
+		forward, up = Vector{0, 0, -1}, Vector{0, 1, 0}
+		right = forward.cross(up)
+	}
+	// Make the axes orthogonal.
+	forward = up.cross(right)
+	up = right.cross(forward)
+	return right.scale(1 / right.length()), up.scale(1 / up.length()), forward.scale(1 / forward.length())
+}
+
+// Attenuation is a distance attenuation model. Attenuation must sync with oto's Attenuation.
+type Attenuation int
+
+const (
+	AttenuationInverse Attenuation = iota
+	AttenuationLinear
+	AttenuationNone
+)
+
+// Emitter is the spatial properties of a player. Emitter must sync with oto's Emitter.
+type Emitter struct {
+	Position Vector
+	Velocity Vector
+
+	// Attenuation is the distance attenuation model.
+	// If AttenuationFunc is not nil, Attenuation is ignored and AttenuationFunc returns the gain for the distance.
+	// AttenuationFunc is called on the render path.
+	Attenuation     Attenuation
+	AttenuationFunc func(distance float64) float64
+
+	// RefDistance is the distance where the gain is 1. If 0, 1 is used.
+	RefDistance float64
+
+	// MaxDistance is the distance where the attenuation stops.
+	// If 0, there is no limit for AttenuationInverse, and 100 is used for AttenuationLinear.
+	MaxDistance float64
+
+	// Rolloff is the rolloff factor. If 0, 1 is used.
+	Rolloff float64
+
+	// AirAbsorption is the amount of the high frequency absorption per distance. If 0, no absorption is applied.
+	AirAbsorption float64
+
+	// DopplerFactor scales the Doppler effect. If 0, no Doppler effect is applied.
+	DopplerFactor float64
+}
+
+// gain returns the attenuation gain at the distance.
+func (e *Emitter) gain(distance float64) float64 {
+	if e.AttenuationFunc != nil {
+		return e.AttenuationFunc(distance)
+	}
+
+	ref := e.RefDistance
+	if ref <= 0 {
+		ref = 1
+	}
+	rolloff := e.Rolloff
+	if rolloff == 0 {
+		rolloff = 1
+	}
+
+	switch e.Attenuation {
+	case AttenuationLinear:
+		maxDistance := e.MaxDistance
+		if maxDistance <= 0 {
+			maxDistance = 100
+		}
+		if maxDistance <= ref {
+			return 1
+		}
+		d := min(max(distance, ref), maxDistance)
+		return max(1-rolloff*(d-ref)/(maxDistance-ref), 0)
+	case AttenuationNone:
+		return 1
+	default:
+		d := max(distance, ref)
+		if e.MaxDistance > 0 {
+			d = min(d, max(e.MaxDistance, ref))
+		}
+		return ref / (ref + rolloff*(d-ref))
+	}
+}
+
+const (
+	defaultSpeedOfSound = 343.3
+	minDopplerPitch     = 0.25
+	maxDopplerPitch     = 4
+	maxCutoffFrequency  = 20000
+)
+
+// speaker is a speaker position on the horizontal plane for panning.
+type speaker struct {
+	index int
+
+	// azimuth is the angle in radians from the front. A positive value is to the right.
+	azimuth float64
+}
+
+// speakerAzimuths is the azimuths in degrees of the speaker positions.
+// A low frequency channel is not used for panning.
+var speakerAzimuths = map[ChannelMask]float64{
+	ChannelFrontLeft:          -30,
+	ChannelFrontRight:         30,
+	ChannelFrontCenter:        0,
+	ChannelBackLeft:           -135,
+	ChannelBackRight:          135,
+	ChannelFrontLeftOfCenter:  -15,
+	ChannelFrontRightOfCenter: 15,
+	ChannelBackCenter:         180,
+	ChannelSideLeft:           -90,
+	ChannelSideRight:          90,
+}
+
+// newSpeakers returns the speakers for panning sorted by their azimuths.
+func newSpeakers(channelCount int, channelMask ChannelMask) []speaker {
+	var speakers []speaker
+	for i, c := range channelMask.Channels() {
+		if i >= channelCount {
+			break
+		}
+		a, ok := speakerAzimuths[c]
+		if !ok {
+			continue
+		}
+		speakers = append(speakers, speaker{
+			index:   i,
+			azimuth: a * math.Pi / 180,
+		})
+	}
+	sort.Slice(speakers, func(i, j int) bool {
+		return speakers[i].azimuth < speakers[j].azimuth
+	})
+	return speakers
+}
+
+// panGains calculates the gains of the channels to place a sound at the azimuth.
+func (m *Mux) panGains(gains []float32, azimuth float64) {
+	clear(gains)
+
+	speakers := m.speakers
+	switch {
+	case len(speakers) == 0:
+		// The channels have no positions. Play the sound at all the channels.
+		g := float32(1 / math.Sqrt(float64(len(gains))))
+		for i := range gains {
+			gains[i] = g
+		}
+	case len(speakers) == 1:
+		gains[speakers[0].index] = 1
+	case len(speakers) == 2:
+		// Equal-power panning between left and right by the lateral component.
+		pan := math.Sin(azimuth)
+		theta := (pan + 1) * math.Pi / 4
+		gains[speakers[0].index] = float32(math.Cos(theta))
+		gains[speakers[1].index] = float32(math.Sin(theta))
+	default:
+		// Equal-power panning between the adjacent speakers around the azimuth.
+		i0, i1 := len(speakers)-1, 0
+		for i := range speakers {
+			if speakers[i].azimuth > azimuth {
+				break
+			}
+			i0, i1 = i, (i+1)%len(speakers)
+		}
+		a0, a1 := speakers[i0].azimuth, speakers[i1].azimuth
+		span := a1 - a0
+		if span <= 0 {
+			span += 2 * math.Pi
+		}
+		offset := azimuth - a0
+		if offset < 0 {
+			offset += 2 * math.Pi
+		}
+		t := min(offset/span, 1)
+		gains[speakers[i0].index] += float32(math.Cos(t * math.Pi / 2))
+		gains[speakers[i1].index] += float32(math.Sin(t * math.Pi / 2))
+	}
+}
+
+// spatialState is the state of a spatialized player on the render path.
+type spatialState struct {
+	initialized bool
+
+	// prevGains and gains are the channel gains for the previous and the current buffer.
+	prevGains []float32
+	gains     []float32
+
+	// s0 and s1 are the last two mono samples of the source, and phase is the position between them for the Doppler effect.
+	s0, s1 float32
+	phase  float64
+
+	// lowPass is the state of the low-pass filter for the air absorption.
+	lowPass float32
+
+	// The fields below are for binaural rendering.
+	// gain and prevGain are the distance attenuation for the current and the previous buffer.
+	// hrir and prevHRIR are the indices of the HRIRs for the current and the previous buffer.
+	// history is the last input samples for the convolution.
+	hrtf     *hrtfSet
+	gain     float32
+	prevGain float32
+	hrir     int
+	prevHRIR int
+	history  []float32
+	ext      []float32
+
+	frames []float32
+	mono   []float32
+}
+
+func (p *Player) Emitter() *Emitter {
+	return p.p.Emitter()
+}
+
+// Emitter returns a copy of the player's emitter, or nil if the player is not spatialized.
+func (p *playerImpl) Emitter() *Emitter {
+	e := p.emitter.Load()
+	if e == nil {
+		return nil
+	}
+	e2 := *e
+	return &e2
+}
+
+func (p *Player) SetEmitter(emitter *Emitter) {
+	p.p.SetEmitter(emitter)
+}
+
+// SetEmitter spatializes the player with the emitter. If emitter is nil, the player is not spatialized.
+// The emitter is copied.
+func (p *playerImpl) SetEmitter(emitter *Emitter) {
+	if emitter == nil {
+		p.emitter.Store(nil)
+		p.renderM.Lock()
+		p.spatial.initialized = false
+		p.renderM.Unlock()
+		return
+	}
+	e := *emitter
+	p.emitter.Store(&e)
+}
+
+// Listener returns the listener.
+func (m *Mux) Listener() Listener {
+	return *m.listener.Load()
+}
+
+// SetListener sets the listener for the spatialized players.
+func (m *Mux) SetListener(listener Listener) {
+	m.listener.Store(&listener)
+}
+
+// updateSpatialParams calculates the channel gains for the current buffer, and returns the pitch and the coefficient of the low-pass filter.
+func (p *playerImpl) updateSpatialParams(e *Emitter, hrtf *hrtfSet) (pitch float64, alpha float32) {
+	l := p.mux.listener.Load()
+	right, up, forward := l.axes()
+
+	rel := e.Position.sub(l.Position)
+	distance := rel.length()
+	gain := float32(e.gain(distance))
+
+	if hrtf != nil {
+		p.spatial.gain = gain
+		// The direction in SOFA's coordinates: X is the front, Y is the left, and Z is the top.
+		p.spatial.hrir = hrtf.nearest(Vector{rel.dot(forward), -rel.dot(right), rel.dot(up)})
+	} else {
+		// The elevation is ignored for panning. A sound just above or below the listener is at the front.
+		azimuth := math.Atan2(rel.dot(right), rel.dot(forward))
+		p.mux.panGains(p.spatial.gains, azimuth)
+		for i := range p.spatial.gains {
+			p.spatial.gains[i] *= gain
+		}
+	}
+
+	pitch = 1
+	if e.DopplerFactor != 0 && distance > 0 {
+		c := l.SpeedOfSound
+		if c <= 0 {
+			c = defaultSpeedOfSound
+		}
+		dir := rel.scale(1 / distance)
+		// The velocities toward each other raise the pitch.
+		vl := min(l.Velocity.dot(dir)*e.DopplerFactor, c*0.9)
+		vs := max(e.Velocity.dot(dir)*e.DopplerFactor, -c*0.9)
+		pitch = min(max((c+vl)/(c+vs), minDopplerPitch), maxDopplerPitch)
+	}
+
+	alpha = 1
+	if e.AirAbsorption > 0 {
+		cutoff := maxCutoffFrequency / (1 + e.AirAbsorption*distance)
+		alpha = float32(1 - math.Exp(-2*math.Pi*cutoff/float64(p.mux.sampleRate)))
+	}
+	return pitch, alpha
+}
+
+// readSpatialAndAdd spatializes the samples of the player and adds them to buf.
+//
+// The source is downmixed to mono, resampled for the Doppler effect, low-pass filtered for the air absorption,
+// and panned to the channels with the distance attenuation. The parameters are updated for each buffer.
+// If the mux has an HRTF, the sound is convolved with the HRIRs for the direction instead of panning.
+//
+// If inaudible is true or the player's volume is 0, the player is a virtual voice. See skipSpatial.
+func (p *playerImpl) readSpatialAndAdd(buf []float32, e *Emitter, inaudible bool) int {
+	if !p.renderM.TryLock() {
+		return 0
+	}
+	defer p.renderM.Unlock()
+
+	// The meter measures the mono samples before panning.
+	var mb meterBlock
+	var meterSamples int
+	defer func() {
+		p.meter.update(&mb, meterSamples, p.mux.meterCoef(len(buf)))
+	}()
+
+	if p.state.Load() != playerPlay {
+		return 0
+	}
+
+	channelCount := p.mux.channelCount
+	frames := len(buf) / channelCount
+	if frames == 0 {
+		return 0
+	}
+
+	s := &p.spatial
+	if len(s.gains) != channelCount {
+		s.gains = make([]float32, channelCount)
+		s.prevGains = make([]float32, channelCount)
+	}
+	// Binaural rendering is available only for stereo.
+	hrtf := p.mux.hrtf.Load()
+	if channelCount != 2 {
+		hrtf = nil
+	}
+	pitch, alpha := p.updateSpatialParams(e, hrtf)
+	if !s.initialized {
+		copy(s.prevGains, s.gains)
+		s.prevGain = s.gain
+		s.s0, s.s1, s.phase, s.lowPass = 0, 0, 0, 0
+		s.hrtf = nil
+		s.initialized = true
+	}
+
+	// Count the source frames consumed in this buffer.
+	var need int
+	phase := s.phase
+	for range frames {
+		phase += pitch
+		for phase >= 1 {
+			phase--
+			need++
+		}
+	}
+
+	// The buffers are allocated only when the driver requests a larger buffer than ever.
+	if cap(s.frames) < need*channelCount {
+		s.frames = make([]float32, need*channelCount)
+	}
+
+	prevVolume := float32(p.prevVolume)
+	volume := float32(p.volume.Load())
+	p.prevVolume = float64(volume)
+
+	if inaudible || (prevVolume == 0 && volume == 0) {
+		p.skipSpatial(need, phase)
+		copy(s.prevGains, s.gains)
+		s.prevGain = s.gain
+		return len(buf)
+	}
+
+	if cap(s.mono) < frames {
+		s.mono = make([]float32, frames)
+	}
+	src := s.frames[:need*channelCount]
+	n := p.pullSamples(src)
+	clear(src[n:])
+	mono := s.mono[:frames]
+
+	var idx int
+	for j := range mono {
+		mono[j] = s.s0 + (s.s1-s.s0)*float32(s.phase)
+		s.phase += pitch
+		for s.phase >= 1 {
+			s.phase--
+			s.s0 = s.s1
+			var v float32
+			for _, x := range src[idx*channelCount : (idx+1)*channelCount] {
+				v += x
+			}
+			s.s1 = v / float32(channelCount)
+			idx++
+		}
+	}
+
+	if alpha < 1 {
+		lp := s.lowPass
+		for j, v := range mono {
+			lp += alpha * (v - lp)
+			mono[j] = lp
+		}
+		s.lowPass = lp
+	}
+
+	mb.add(mono, volume)
+	meterSamples = len(mono)
+	p.analyzer.Load().write(mono, volume, 1)
+
+	if hrtf != nil {
+		p.convolveAndAdd(buf, mono, hrtf, s.prevGain*prevVolume, s.gain*volume)
+		s.prevGain = s.gain
+	} else {
+		for c := range channelCount {
+			g0 := s.prevGains[c] * prevVolume
+			g1 := s.gains[c] * volume
+			if g0 == 0 && g1 == 0 {
+				continue
+			}
+			for j, v := range mono {
+				rate := float32(j) / float32(frames)
+				buf[j*channelCount+c] += v * (g0 + (g1-g0)*rate)
+			}
+		}
+	}
+	copy(s.prevGains, s.gains)
+
+	return len(buf)
+}
+
+// skipSpatial advances a virtual voice by need source frames without the downmix, the resampling, the filter and the convolution.
+// phase is the position between the source frames after this buffer.
+//
+// The interpolation continues from the last skipped frames, the low-pass filter continues from the last skipped frame as if it had settled,
+// and the convolution restarts without history.
+// The discontinuity is not audible, as a virtual voice becomes audible with a volume ramp from 0.
+//
+// When skipSpatial is called, the mutex renderM must be locked.
+func (p *playerImpl) skipSpatial(need int, phase float64) {
+	s := &p.spatial
+	s.phase = phase
+	s.hrtf = nil
+	if need == 0 {
+		return
+	}
+
+	// The interpolation needs the last two frames.
+	channelCount := p.mux.channelCount
+	k := min(need, 2)
+	var last []float32
+	if p.render != nil {
+		// A render function is called for all the frames anyway so that its state advances.
+		src := s.frames[:need*channelCount]
+		n := p.pullSamples(src)
+		clear(src[n:])
+		last = src[(need-k)*channelCount:]
+	} else {
+		p.skipSamples((need - k) * channelCount)
+		last = s.frames[:k*channelCount]
+		n := p.pullSamples(last)
+		clear(last[n:])
+	}
+
+	for i := range k {
+		var v float32
+		for _, x := range last[i*channelCount : (i+1)*channelCount] {
+			v += x
+		}
+		s.s0 = s.s1
+		s.s1 = v / float32(channelCount)
+	}
+	s.lowPass = s.s1
+}
+
+// skipSamples advances the player's position by n samples in the mux's layout without reading them.
+// skipSamples is not available for a render function.
+//
+// When skipSamples is called, the mutex renderM must be locked.
+func (p *playerImpl) skipSamples(n int) {
+	if p.sound != nil {
+		samples := p.sound.samples
+		switch {
+		case p.loop && len(samples) > 0:
+			p.soundPos = (p.soundPos + n) % len(samples)
+		default:
+			p.soundPos = min(p.soundPos+n, len(samples))
+			if p.soundPos >= len(samples) {
+				p.finish()
+			}
+		}
+		return
+	}
+
+	// Load eof before tail. eof is stored after the last samples are written.
+	eof := p.eof.Load()
+	head, tail := p.head.Load(), p.tail.Load()
+	n = min(int(tail-head), n)
+	p.head.Store(head + int64(n))
+	if eof {
+		if head+int64(n) == tail {
+			p.finish()
+		}
+	} else {
+		p.wake()
+	}
+}
+
+// pullSamples moves the player's samples in the mux's layout to dst, and returns the number of the samples.
+//
+// When pullSamples is called, the mutex renderM must be locked.
+func (p *playerImpl) pullSamples(dst []float32) int {
+	switch {
+	case p.render != nil:
+		clear(dst)
+		p.render(dst)
+		return len(dst)
+
+	case p.sound != nil:
+		samples := p.sound.samples
+		var n int
+		for n < len(dst) {
+			if p.soundPos >= len(samples) {
+				if !p.loop || len(samples) == 0 {
+					p.finish()
+					break
+				}
+				p.soundPos = 0
+			}
+			m := copy(dst[n:], samples[p.soundPos:])
+			p.soundPos += m
+			n += m
+		}
+		if p.soundPos >= len(samples) && !p.loop {
+			p.finish()
+		}
+		return n
+
+	default:
+		// Load eof before tail. eof is stored after the last samples are written.
+		eof := p.eof.Load()
+		head, tail := p.head.Load(), p.tail.Load()
+		n := min(int(tail-head), len(dst))
+		if n > 0 {
+			idx := int(head % int64(len(p.ring)))
+			n0 := copy(dst[:n], p.ring[idx:])
+			copy(dst[n0:n], p.ring)
+		}
+		p.head.Store(head + int64(n))
+
+		if eof {
+			if head+int64(n) == tail {
+				p.finish()
+			}
+		} else {
+			p.wake()
+		}
+		return n
+	}
+}
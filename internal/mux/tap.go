@@ -0,0 +1,235 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mux
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// outputTapBufferDuration is the duration of the samples an OutputTap can hold, in 1/outputTapBufferDurationDenom seconds.
+const (
+	outputTapBufferDuration      = 1
+	outputTapBufferDurationDenom = 2
+)
+
+// outputTapChunkSize is the maximum number of the samples an OutputTap encodes and writes at once.
+const outputTapChunkSize = 4096
+
+// OutputTap receives a copy of the output of a Mux, and writes it to an io.Writer on its own goroutine.
+//
+// The render path copies the output to a ring buffer without blocking.
+// If the ring buffer doesn't have enough space for a block, e.g. when the writer is slow, the block is dropped.
+// A block larger than the ring buffer is never dropped: the render path switches to a new larger ring buffer,
+// and the writing goroutine moves to it after it finishes the old one.
+type OutputTap struct {
+	mux          *Mux
+	w            io.Writer
+	format       Format
+	channelCount int
+
+	// writeRing is the ring buffer the render path writes to, and used only on the render path.
+	// readRing is the ring buffer the writing goroutine reads from, and used only on the writing goroutine.
+	writeRing *tapRing
+	readRing  *tapRing
+
+	dropped atomic.Int64
+	failed  atomic.Bool
+	closed  atomic.Bool
+
+	// err is the error from the writer, and protected by m.
+	err error
+	m   sync.Mutex
+
+	wakeCh chan struct{}
+	doneCh chan struct{}
+}
+
+// tapRing is a ring buffer of the output samples.
+// The render path writes to [tail, head+len(buf)), and the writing goroutine reads [head, tail).
+type tapRing struct {
+	buf  []float32
+	head atomic.Int64
+	tail atomic.Int64
+
+	// next is the ring buffer that replaces this one. No samples are written to this one after next is set.
+	next atomic.Pointer[tapRing]
+}
+
+// AddOutputTap adds an OutputTap writing the output to w in the format.
+// The output has the channels after SetOutputChannels.
+//
+// AddOutputTap is concurrent-safe.
+func (m *Mux) AddOutputTap(w io.Writer, format Format) *OutputTap {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	size := max(m.sampleRate*outputTapBufferDuration/outputTapBufferDurationDenom, 1) * m.outputChannelCount
+	ring := &tapRing{
+		buf: make([]float32, size),
+	}
+	t := &OutputTap{
+		mux:          m,
+		w:            w,
+		format:       format,
+		channelCount: m.outputChannelCount,
+		writeRing:    ring,
+		readRing:     ring,
+		wakeCh:       make(chan struct{}, 1),
+		doneCh:       make(chan struct{}),
+	}
+	go t.loop()
+
+	taps := append([]*OutputTap{}, *m.taps.Load()...)
+	taps = append(taps, t)
+	m.taps.Store(&taps)
+	return t
+}
+
+// writeToTaps copies buf to the output taps.
+//
+// writeToTaps never blocks.
+func (m *Mux) writeToTaps(buf []float32) {
+	for _, t := range *m.taps.Load() {
+		t.write(buf)
+	}
+}
+
+// write copies buf to the ring buffer, or drops buf if there is not enough space.
+//
+// write is called on the render path, and never blocks.
+func (t *OutputTap) write(buf []float32) {
+	if t.failed.Load() {
+		return
+	}
+
+	r := t.writeRing
+	if len(buf) > len(r.buf) {
+		// The ring buffer grows only when the driver passes a larger buffer than ever.
+		// Leave room for the next block while the writer encodes this one.
+		next := &tapRing{
+			buf: make([]float32, 2*len(buf)),
+		}
+		r.next.Store(next)
+		t.writeRing = next
+		r = next
+	}
+
+	head, tail := r.head.Load(), r.tail.Load()
+	if len(r.buf)-int(tail-head) < len(buf) {
+		t.dropped.Add(1)
+		t.wake()
+		return
+	}
+	for len(buf) > 0 {
+		idx := int(tail % int64(len(r.buf)))
+		n := copy(r.buf[idx:], buf)
+		buf = buf[n:]
+		tail += int64(n)
+	}
+	r.tail.Store(tail)
+	t.wake()
+}
+
+// wake wakes the writing goroutine. wake never blocks.
+func (t *OutputTap) wake() {
+	select {
+	case t.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// loop writes the samples in the ring buffer to the writer until the tap is closed.
+func (t *OutputTap) loop() {
+	defer close(t.doneCh)
+
+	var bs []byte
+	for {
+		// Load closed before tail so that the samples written before closing are flushed.
+		// Load next before tail so that the samples written before switching the ring buffers are flushed.
+		closed := t.closed.Load()
+		r := t.readRing
+		next := r.next.Load()
+		head, tail := r.head.Load(), r.tail.Load()
+		if head == tail {
+			if next != nil {
+				t.readRing = next
+				continue
+			}
+			if closed {
+				return
+			}
+			<-t.wakeCh
+			continue
+		}
+
+		idx := int(head % int64(len(r.buf)))
+		n := min(int(tail-head), len(r.buf)-idx, outputTapChunkSize)
+		bs = t.format.AppendEncoded(bs[:0], r.buf[idx:idx+n])
+		r.head.Store(head + int64(n))
+
+		if _, err := t.w.Write(bs); err != nil {
+			t.m.Lock()
+			t.err = err
+			t.m.Unlock()
+			t.failed.Store(true)
+			return
+		}
+	}
+}
+
+// ChannelCount returns the number of the channels of the written samples.
+func (t *OutputTap) ChannelCount() int {
+	return t.channelCount
+}
+
+// DroppedBlocks returns the number of the output blocks dropped because the writer was too slow.
+//
+// DroppedBlocks is concurrent-safe.
+func (t *OutputTap) DroppedBlocks() int64 {
+	return t.dropped.Load()
+}
+
+// Err returns the error from the writer. After an error, the tap doesn't write any more.
+//
+// Err is concurrent-safe.
+func (t *OutputTap) Err() error {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.err
+}
+
+// Close removes the tap from the mux, and waits for the buffered samples to be written.
+// Close returns the error from the writer if any.
+//
+// Close is concurrent-safe.
+func (t *OutputTap) Close() error {
+	m := t.mux
+	m.m.Lock()
+	var taps []*OutputTap
+	for _, tap := range *m.taps.Load() {
+		if tap != t {
+			taps = append(taps, tap)
+		}
+	}
+	m.taps.Store(&taps)
+	m.m.Unlock()
+
+	t.closed.Store(true)
+	t.wake()
+	<-t.doneCh
+	return t.Err()
+}
@@ -38,6 +38,7 @@ public:
   const char *Pause();
   const char *Resume();
   const char *Close();
+  double LatencyMillis();
   const char *AppendBuffer(float *buf, size_t len);
 
   oboe::DataCallbackResult onAudioReady(oboe::AudioStream *oboe_stream,
@@ -56,6 +57,7 @@ private:
   // All the member variables other than the thread must be initialized before
   // the thread.
   std::vector<float> buf_;
+  bool closing_ = false;
   std::mutex mutex_;
   std::condition_variable cond_;
   std::unique_ptr<std::thread> thread_;
@@ -125,20 +127,54 @@ const char *Stream::Resume() {
 }
 
 const char *Stream::Close() {
-  // Nobody calls this so far.
-  if (!stream_) {
-    return nullptr;
-  }
-  if (oboe::Result result = stream_->stop(); result != oboe::Result::OK) {
-    return oboe::convertToText(result);
+  if (stream_) {
+    // Stop the stream before the thread, as onAudioReady waits for the
+    // thread's data.
+    if (oboe::Result result = stream_->stop(); result != oboe::Result::OK) {
+      return oboe::convertToText(result);
+    }
+    if (oboe::Result result = stream_->close(); result != oboe::Result::OK) {
+      return oboe::convertToText(result);
+    }
+    stream_.reset();
   }
-  if (oboe::Result result = stream_->close(); result != oboe::Result::OK) {
-    return oboe::convertToText(result);
+
+  if (thread_) {
+    {
+      std::lock_guard<std::mutex> lock{mutex_};
+      closing_ = true;
+      cond_.notify_all();
+    }
+    thread_->join();
+    thread_.reset();
   }
-  stream_.reset();
+
+  std::lock_guard<std::mutex> lock{mutex_};
+  closing_ = false;
+  buf_.clear();
   return nullptr;
 }
 
+double Stream::LatencyMillis() {
+  if (!stream_) {
+    return 0;
+  }
+
+  // calculateLatencyMillis is not available with OpenSL ES. Use the stream's
+  // buffer size instead.
+  double latency = 0;
+  if (oboe::ResultWithValue<double> result = stream_->calculateLatencyMillis();
+      result) {
+    latency = result.value();
+  } else {
+    latency = 1000.0 * stream_->getBufferSizeInFrames() / sample_rate_;
+  }
+
+  // The data buffered for the callback is played after the stream's data.
+  std::lock_guard<std::mutex> lock{mutex_};
+  return latency + 1000.0 * buf_.size() / channel_num_ / sample_rate_;
+}
+
 oboe::DataCallbackResult Stream::onAudioReady(oboe::AudioStream *oboe_stream,
                                               void *audio_data,
                                               int32_t num_frames) {
@@ -163,7 +199,12 @@ void Stream::Loop(int num_frames) {
   for (;;) {
     {
       std::unique_lock<std::mutex> lock{mutex_};
-      cond_.wait(lock, [this, &tmp] { return buf_.size() < tmp.size(); });
+      cond_.wait(lock, [this, &tmp] {
+        return buf_.size() < tmp.size() || closing_;
+      });
+      if (closing_) {
+        return;
+      }
     }
     oto_oboe_read(&tmp[0], tmp.size());
     {
@@ -188,4 +229,10 @@ const char *oto_oboe_Suspend() { return Stream::GetInstance().Pause(); }
 
 const char *oto_oboe_Resume() { return Stream::GetInstance().Resume(); }
 
+const char *oto_oboe_Close() { return Stream::GetInstance().Close(); }
+
+double oto_oboe_LatencyMillis() {
+  return Stream::GetInstance().LatencyMillis();
+}
+
 } // extern "C"
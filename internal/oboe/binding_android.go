@@ -26,6 +26,7 @@ import "C"
 
 import (
 	"fmt"
+	"time"
 	"unsafe"
 )
 
@@ -54,6 +55,19 @@ func Resume() error {
 	return nil
 }
 
+// Close stops and closes the stream. Play can be called again after Close.
+func Close() error {
+	if msg := C.oto_oboe_Close(); msg != nil {
+		return fmt.Errorf("oboe: Close failed: %s", C.GoString(msg))
+	}
+	return nil
+}
+
+// Latency returns the latency of the stream. Latency returns 0 if the stream is not opened.
+func Latency() time.Duration {
+	return time.Duration(float64(C.oto_oboe_LatencyMillis()) * float64(time.Millisecond))
+}
+
 //export oto_oboe_read
 func oto_oboe_read(buf *C.float, len C.size_t) {
 	theReadFunc(unsafe.Slice((*float32)(unsafe.Pointer(buf)), len))
@@ -29,6 +29,8 @@ const char *oto_oboe_Play(int sample_rate, int channel_num,
                           int buffer_size_in_bytes);
 const char *oto_oboe_Suspend();
 const char *oto_oboe_Resume();
+const char *oto_oboe_Close();
+double oto_oboe_LatencyMillis();
 
 #ifdef __cplusplus
 }
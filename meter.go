@@ -0,0 +1,79 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"time"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// Meter is a snapshot of a level meter.
+//
+// The meters are updated for each buffer of the audio device, and can be read at any time without blocking the audio.
+type Meter struct {
+	// Peak is the peak absolute value of the samples.
+	// Peak holds the maximum value and decays exponentially with the time constant of the meters.
+	Peak float64
+
+	// RMS is the root mean square of the samples, averaged exponentially with the time constant of the meters.
+	RMS float64
+
+	// Clips is the total number of the samples whose absolute values exceeded 1.
+	Clips int64
+}
+
+func meterFromMux(m mux.Meter) Meter {
+	return Meter{
+		Peak:  m.Peak,
+		RMS:   m.RMS,
+		Clips: m.Clips,
+	}
+}
+
+// Meter returns the level meter of the player.
+// The meter measures the player's samples after its volume is applied.
+// For a player with an emitter, the meter measures the mono samples before panning and the distance attenuation.
+func (p *Player) Meter() Meter {
+	return meterFromMux(p.player.Meter())
+}
+
+// Meter returns the level meter of the bus.
+// The meter measures the mixed samples of the bus's players after the bus's volume is applied.
+func (b *Bus) Meter() Meter {
+	return meterFromMux(b.bus.Meter())
+}
+
+// Meter returns the level meter of the final output of the context.
+//
+// Meter is concurrent-safe.
+func (c *Context) Meter() Meter {
+	return meterFromMux(c.mux.Meter())
+}
+
+// MeterDecay returns the time constant of the level meters.
+//
+// MeterDecay is concurrent-safe.
+func (c *Context) MeterDecay() time.Duration {
+	return c.mux.MeterDecay()
+}
+
+// SetMeterDecay sets the time constant of the level meters of the context and all its buses and players.
+// The default value is 300ms.
+//
+// SetMeterDecay is concurrent-safe.
+func (c *Context) SetMeterDecay(decay time.Duration) {
+	c.mux.SetMeterDecay(decay)
+}
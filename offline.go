@@ -0,0 +1,202 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// offlineBlockFrames is the number of the frames an OfflineContext renders at once.
+//
+// The blocks are fixed so that the same calls always render the same output, e.g. for volume ramps.
+const offlineBlockFrames = 512
+
+// OfflineContext is a context that renders the output on demand without an audio device.
+//
+// An OfflineContext works exactly like a Context, with the same players, volumes and effects,
+// but the output is rendered only when Render, RenderWAV or ReadFloat32s is called, as fast as the CPU allows.
+// This is useful e.g. to bake audio or to generate test fixtures.
+//
+// The players' sources are read synchronously before each block is rendered.
+// Then, the output is deterministic for the same sources and the same calls,
+// unless a player's buffer is smaller than 512 frames or a source doesn't provide data immediately.
+type OfflineContext struct {
+	*Context
+
+	buf []float32
+	bs  []byte
+	m   sync.Mutex
+}
+
+// offlineDriver is a driver doing nothing for an OfflineContext.
+type offlineDriver struct{}
+
+func (offlineDriver) Suspend() error {
+	return nil
+}
+
+func (offlineDriver) Resume() error {
+	return nil
+}
+
+func (offlineDriver) Err() error {
+	return nil
+}
+
+func (offlineDriver) Close() error {
+	return nil
+}
+
+// NewOfflineContext creates a new offline context with the given options.
+//
+// BufferSize and ApplicationName in options are ignored.
+// Any number of offline contexts can be created on any platform.
+func NewOfflineContext(options *NewContextOptions) (*OfflineContext, error) {
+	channelMask, _, err := channelLayout(options)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineContext{
+		Context: &Context{
+			driver:       offlineDriver{},
+			mux:          mux.NewWithChannelMask(options.SampleRate, options.ChannelCount, mux.Format(options.Format), mux.ChannelMask(channelMask)),
+			sampleRate:   options.SampleRate,
+			channelCount: options.ChannelCount,
+			channelMask:  channelMask,
+			format:       options.Format,
+		},
+	}, nil
+}
+
+// ReadFloat32s renders the output to buf as float32 values.
+// The length of buf must be a multiple of the channel count.
+//
+// ReadFloat32s is concurrent-safe.
+func (c *OfflineContext) ReadFloat32s(buf []float32) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	renderBlocks(c.mux, buf, c.channelCount)
+}
+
+// renderBlocks renders buf in fixed blocks, reading the players' sources synchronously before each block.
+func renderBlocks(m *mux.Mux, buf []float32, channelCount int) {
+	for len(buf) > 0 {
+		n := min(len(buf), offlineBlockFrames*channelCount)
+		m.FillBuffers()
+		m.ReadFloat32s(buf[:n])
+		buf = buf[n:]
+	}
+}
+
+// Render renders the output of the given number of frames, and writes it to w in the format.
+//
+// Render is concurrent-safe.
+func (c *OfflineContext) Render(w io.Writer, frames int, format Format) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.render(w, frames, format)
+}
+
+// render renders the output of the given number of frames, and writes it to w in the format.
+//
+// When render is called, the mutex m must be locked.
+func (c *OfflineContext) render(w io.Writer, frames int, format Format) error {
+	if cap(c.buf) < offlineBlockFrames*c.channelCount {
+		c.buf = make([]float32, offlineBlockFrames*c.channelCount)
+	}
+	for frames > 0 {
+		n := min(frames, offlineBlockFrames)
+		buf := c.buf[:n*c.channelCount]
+		renderBlocks(c.mux, buf, c.channelCount)
+		c.bs = mux.Format(format).AppendEncoded(c.bs[:0], buf)
+		if _, err := w.Write(c.bs); err != nil {
+			return err
+		}
+		frames -= n
+	}
+	return nil
+}
+
+// RenderWAV renders the output of the given number of frames, and writes it to w as a WAV file in the format.
+//
+// RenderWAV is concurrent-safe.
+func (c *OfflineContext) RenderWAV(w io.Writer, frames int, format Format) error {
+	dataSize := int64(frames) * int64(c.channelCount) * int64(mux.Format(format).ByteLength())
+	// The header must be smaller than 100 bytes.
+	if dataSize+100 > math.MaxUint32 {
+		return fmt.Errorf("oto: too many frames for a WAV file: %d", frames)
+	}
+
+	// Keep the lock across the header and the frames not to interleave another rendering to w.
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if _, err := w.Write(wavHeader(c.sampleRate, c.channelCount, c.channelMask, format, uint32(dataSize))); err != nil {
+		return err
+	}
+	return c.render(w, frames, format)
+}
+
+// wavHeader returns the header of a WAV file.
+// WAVE_FORMAT_EXTENSIBLE is used for more than two channels.
+func wavHeader(sampleRate int, channelCount int, channelMask ChannelMask, format Format, dataSize uint32) []byte {
+	const (
+		waveFormatPCM        = 0x0001
+		waveFormatIEEEFloat  = 0x0003
+		waveFormatExtensible = 0xfffe
+	)
+
+	bytesPerSample := mux.Format(format).ByteLength()
+	tag := uint16(waveFormatPCM)
+	if format == FormatFloat32LE {
+		tag = waveFormatIEEEFloat
+	}
+
+	var fmtChunk []byte
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, tag)
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(channelCount))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate))
+	fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(sampleRate*channelCount*bytesPerSample))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(channelCount*bytesPerSample))
+	fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(bytesPerSample*8))
+	if channelCount > 2 {
+		binary.LittleEndian.PutUint16(fmtChunk, waveFormatExtensible)
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, 22)
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, uint16(bytesPerSample*8))
+		fmtChunk = binary.LittleEndian.AppendUint32(fmtChunk, uint32(channelMask))
+		// The sub format GUID is the format tag followed by 00000000-0010-8000-00AA00389B71.
+		fmtChunk = binary.LittleEndian.AppendUint16(fmtChunk, tag)
+		fmtChunk = append(fmtChunk, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71)
+	}
+
+	var header []byte
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(4+8+len(fmtChunk)+8)+dataSize)
+	header = append(header, "WAVE"...)
+	header = append(header, "fmt "...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(fmtChunk)))
+	header = append(header, fmtChunk...)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, dataSize)
+	return header
+}
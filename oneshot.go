@@ -0,0 +1,68 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"io"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// SoundHandle is a handle of a sound played by PlayOnce.
+//
+// All the functions of a SoundHandle are concurrent-safe.
+type SoundHandle struct {
+	player *mux.Player
+}
+
+// Stop stops the sound and frees it. Stop does nothing if the sound has already finished.
+func (h *SoundHandle) Stop() {
+	_ = h.player.Close()
+}
+
+// IsPlaying reports whether the sound is still playing.
+func (h *SoundHandle) IsPlaying() bool {
+	return h.player.IsPlaying()
+}
+
+// PlayOnce creates a new player from src, plays it, and frees it automatically when it reaches the end of src.
+//
+// Unlike a player returned by NewPlayer, the sound keeps playing until the end even if the returned handle is not referenced.
+// The returned handle is needed only for stopping the sound in the middle.
+//
+// options can be nil. In this case, the default options are used. Play of options is ignored.
+// If Loop of options is true, the sound never reaches the end and keeps playing until Stop is called.
+//
+// PlayOnce returns an error if options are invalid. See NewPlayerWithOptions.
+//
+// PlayOnce is concurrent-safe.
+func (c *Context) PlayOnce(src io.Reader, options *NewPlayerOptions) (*SoundHandle, error) {
+	var p *Player
+	if options == nil {
+		p = c.NewPlayer(src)
+	} else {
+		op := *options
+		op.Play = false
+		var err error
+		p, err = c.NewPlayerWithOptions(src, &op)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.player.PlayOnce()
+	return &SoundHandle{
+		player: p.player,
+	}, nil
+}
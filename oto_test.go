@@ -16,20 +16,33 @@ package oto_test
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"os"
+	"runtime"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/ebitengine/oto/v3"
 )
 
-var theContext *oto.Context
+var (
+	theContext *oto.Context
+	theDevice  *oto.VirtualDevice
+)
 
 func TestMain(m *testing.M) {
+	// Use a virtual device so that the tests run without sound hardware.
+	theDevice = oto.NewVirtualDevice()
 	op := &oto.NewContextOptions{}
 	op.SampleRate = 48000
 	op.ChannelCount = 2
 	op.Format = oto.FormatFloat32LE
+	op.VirtualDevice = theDevice
 	ctx, ready, err := oto.NewContext(op)
 	if err != nil {
 		panic(err)
@@ -44,7 +57,7 @@ func TestEmptyPlayer(t *testing.T) {
 	p := theContext.NewPlayer(bs)
 	p.Play()
 	for p.IsPlaying() {
-		time.Sleep(time.Millisecond)
+		theDevice.Advance(256)
 	}
 }
 
@@ -56,7 +69,842 @@ func TestSetBufferSize(t *testing.T) {
 		p.Play()
 		p.SetBufferSize(256)
 		for p.IsPlaying() {
-			time.Sleep(time.Millisecond)
+			theDevice.Advance(256)
+		}
+	}
+}
+
+func renderOffline(t *testing.T, src []byte) []byte {
+	ctx, err := oto.NewOfflineContext(&oto.NewContextOptions{
+		SampleRate:   48000,
+		ChannelCount: 2,
+		Format:       oto.FormatSignedInt16LE,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	volume := 0.5
+	format := oto.FormatSignedInt16LE
+	p, err := ctx.NewPlayerWithOptions(bytes.NewReader(src), &oto.NewPlayerOptions{
+		SampleRate:   44100,
+		ChannelCount: 1,
+		Format:       &format,
+		Volume:       &volume,
+		Play:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := ctx.RenderWAV(&out, 48000, oto.FormatSignedInt16LE); err != nil {
+		t.Fatal(err)
+	}
+	runtime.KeepAlive(p)
+	return out.Bytes()
+}
+
+// TestPlatformDriver plays a sound with the platform's audio driver instead of a virtual device.
+func TestPlatformDriver(t *testing.T) {
+	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:   48000,
+		ChannelCount: 2,
+		Format:       oto.FormatFloat32LE,
+	})
+	if err != nil {
+		t.Skipf("no audio backend is available: %v", err)
+	}
+	defer func() {
+		_ = ctx.Close()
+	}()
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Skip("the audio backend is not ready")
+	}
+	if err := ctx.Err(); err != nil {
+		t.Skipf("no audio backend is available: %v", err)
+	}
+
+	// 100ms of silence.
+	p := ctx.NewPlayer(bytes.NewReader(make([]byte, 4800*2*4)))
+	p.Play()
+	for deadline := time.Now().Add(5 * time.Second); p.IsPlaying(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the player didn't finish")
+		}
+	}
+	if err := p.Err(); err != nil {
+		t.Error(err)
+	}
+	if err := ctx.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOfflineContext(t *testing.T) {
+	src := make([]byte, 44100*2)
+	for i := 0; i < len(src); i += 2 {
+		v := int16(10000 * math.Sin(2*math.Pi*440*float64(i/2)/44100))
+		src[i] = byte(v)
+		src[i+1] = byte(v >> 8)
+	}
+
+	out0 := renderOffline(t, src)
+	out1 := renderOffline(t, src)
+	if !bytes.Equal(out0, out1) {
+		t.Errorf("offline rendering is not deterministic")
+	}
+
+	if got, want := len(out0), 44+48000*2*2; got != want {
+		t.Fatalf("len(out): got: %d, want: %d", got, want)
+	}
+	if got, want := string(out0[:4]), "RIFF"; got != want {
+		t.Errorf("RIFF: got: %q, want: %q", got, want)
+	}
+	if got, want := string(out0[36:40]), "data"; got != want {
+		t.Errorf("data: got: %q, want: %q", got, want)
+	}
+
+	// The source is not silent after resampling.
+	var peak int16
+	for i := 44; i < len(out0); i += 2 {
+		peak = max(peak, int16(out0[i])|int16(out0[i+1])<<8)
+	}
+	if peak < 4000 || peak > 6000 {
+		t.Errorf("peak: got: %d, want: about 5000", peak)
+	}
+}
+
+func TestVirtualDevice(t *testing.T) {
+	d := oto.NewVirtualDevice()
+	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:    48000,
+		ChannelCount:  2,
+		Format:        oto.FormatFloat32LE,
+		VirtualDevice: d,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ready
+
+	// A virtual device cannot be shared by contexts.
+	if _, _, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:    48000,
+		ChannelCount:  2,
+		VirtualDevice: d,
+	}); err == nil {
+		t.Errorf("NewContext with a used virtual device must fail")
+	}
+
+	// 1000 frames of 0.5.
+	src := make([]byte, 1000*2*4)
+	for i := 0; i < len(src); i += 4 {
+		binary.LittleEndian.PutUint32(src[i:], math.Float32bits(0.5))
+	}
+	p := ctx.NewPlayer(bytes.NewReader(src))
+	p.Play()
+
+	out := d.Advance(600)
+	if got, want := len(out), 600*2; got != want {
+		t.Fatalf("len(out): got: %d, want: %d", got, want)
+	}
+	for i, v := range out {
+		if v != 0.5 {
+			t.Fatalf("out[%d]: got: %v, want: 0.5", i, v)
+		}
+	}
+
+	// A suspended context outputs silence, and the player doesn't advance.
+	if err := ctx.Suspend(); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range d.Advance(600) {
+		if v != 0 {
+			t.Fatalf("out[%d] while suspending: got: %v, want: 0", i, v)
+		}
+	}
+	if err := ctx.Resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	out = d.Advance(600)
+	for i, v := range out[:400*2] {
+		if v != 0.5 {
+			t.Fatalf("out[%d] after resuming: got: %v, want: 0.5", i, v)
+		}
+	}
+	for i, v := range out[400*2:] {
+		if v != 0 {
+			t.Fatalf("out[%d] after the end: got: %v, want: 0", i+400*2, v)
+		}
+	}
+	if p.IsPlaying() {
+		t.Errorf("IsPlaying() after the end: got: true, want: false")
+	}
+	if got, want := d.Position(), int64(1800); got != want {
+		t.Errorf("Position(): got: %d, want: %d", got, want)
+	}
+}
+
+func TestNewPlayerWithOptionsDefaults(t *testing.T) {
+	ctx, d := newVirtualContext(t)
+
+	// The fields not specified are the context's.
+	format := oto.FormatFloat32LE
+	for _, op := range []*oto.NewPlayerOptions{
+		{SampleRate: 48000},
+		{ChannelCount: 2},
+		{Format: &format},
+		{BufferSize: 10 * time.Millisecond},
+	} {
+		p, err := ctx.NewPlayerWithOptions(bytes.NewReader(float32Frames(100, 0.5)), op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Play()
+		out := d.Advance(200)
+		for i, v := range out[:100*2] {
+			if v != 0.5 {
+				t.Fatalf("%+v: out[%d]: got: %v, want: 0.5", op, i, v)
+			}
+		}
+		for i, v := range out[100*2:] {
+			if v != 0 {
+				t.Fatalf("%+v: out[%d] after the end: got: %v, want: 0", op, i+100*2, v)
+			}
+		}
+	}
+
+	// A mono source without a sample rate is played for the same duration.
+	src := make([]byte, 100*4)
+	for i := 0; i < len(src); i += 4 {
+		binary.LittleEndian.PutUint32(src[i:], math.Float32bits(0.5))
+	}
+	p, err := ctx.NewPlayerWithOptions(bytes.NewReader(src), &oto.NewPlayerOptions{
+		ChannelCount: 1,
+		Play:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := d.Advance(200)
+	for i := range 200 {
+		if got, want := out[2*i] != 0, i < 100; got != want {
+			t.Fatalf("out[%d] is audible: got: %t, want: %t", 2*i, got, want)
+		}
+	}
+	_ = p.Close()
+}
+
+func TestExplicitFloat32Format(t *testing.T) {
+	d := oto.NewVirtualDevice()
+	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:    48000,
+		ChannelCount:  2,
+		Format:        oto.FormatSignedInt16LE,
+		VirtualDevice: d,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ready
+	defer func() {
+		_ = ctx.Close()
+	}()
+
+	// 32-bit floats can be specified explicitly in a context of another format.
+	format := oto.FormatFloat32LE
+	p, err := ctx.NewPlayerWithOptions(bytes.NewReader(float32Frames(100, 0.5)), &oto.NewPlayerOptions{
+		Format: &format,
+		Play:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range d.Advance(100) {
+		if v != 0.5 {
+			t.Fatalf("player: out[%d]: got: %v, want: 0.5", i, v)
 		}
 	}
+	_ = p.Close()
+
+	sb, err := ctx.NewSoundBuffer(bytes.NewReader(float32Frames(100, 0.5)), &oto.NewSoundBufferOptions{
+		Format: &format,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = ctx.NewPlayerFromBuffer(sb, &oto.NewPlayerOptions{Play: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range d.Advance(100) {
+		if v != 0.5 {
+			t.Fatalf("sound buffer: out[%d]: got: %v, want: 0.5", i, v)
+		}
+	}
+	_ = p.Close()
+}
+
+func TestBus(t *testing.T) {
+	ctx, d := newVirtualContext(t)
+
+	bus := ctx.NewBus()
+	bus.SetVolume(0.5)
+	p0, err := ctx.NewPlayerWithOptions(bytes.NewReader(float32Frames(1000, 0.5)), &oto.NewPlayerOptions{
+		Play: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb, err := ctx.NewSoundBuffer(bytes.NewReader(float32Frames(1000, 0.5)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := ctx.NewPlayerFromBuffer(sb, &oto.NewPlayerOptions{
+		Bus:  bus,
+		Play: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first block ramps the bus's volume.
+	d.Advance(100)
+	for i, v := range d.Advance(100) {
+		if v != 0.75 {
+			t.Fatalf("out[%d]: got: %v, want: 0.75", i, v)
+		}
+	}
+
+	bus.Mute()
+	if !bus.IsMuted() {
+		t.Errorf("IsMuted() after Mute: got: false, want: true")
+	}
+	d.Advance(100)
+	for i, v := range d.Advance(100) {
+		if v != 0.5 {
+			t.Fatalf("muted: out[%d]: got: %v, want: 0.5", i, v)
+		}
+	}
+	_ = p0.Close()
+	_ = p1.Close()
+
+	// A bus cannot be used in another context.
+	other, _ := newVirtualContext(t)
+	if _, err := other.NewPlayerWithOptions(bytes.NewReader(nil), &oto.NewPlayerOptions{Bus: bus}); err == nil {
+		t.Errorf("NewPlayerWithOptions with a bus of another context must fail")
+	}
+	if _, err := other.NewPlayerFromBuffer(sb, &oto.NewPlayerOptions{Bus: bus}); err == nil {
+		t.Errorf("NewPlayerFromBuffer with a bus of another context must fail")
+	}
+}
+
+func TestNewPlayerWithOptionsInvalid(t *testing.T) {
+	format := oto.Format(100)
+	for _, op := range []*oto.NewPlayerOptions{
+		{SampleRate: -1},
+		{ChannelCount: -1},
+		{Format: &format},
+		{ChannelCount: 2, ChannelMask: oto.ChannelMaskSurround51},
+		{BufferSize: -time.Second},
+		// The context has 2 channels.
+		{ChannelMatrix: [][]float32{{1, 1}}},
+		{ChannelCount: 1, ChannelMatrix: [][]float32{{1}, {1, 0}}},
+	} {
+		if _, err := theContext.NewPlayerWithOptions(bytes.NewReader(nil), op); err == nil {
+			t.Errorf("%+v: NewPlayerWithOptions must fail", op)
+		}
+		if _, err := theContext.PlayOnce(bytes.NewReader(nil), op); err == nil {
+			t.Errorf("%+v: PlayOnce must fail", op)
+		}
+	}
+
+	if _, err := theContext.NewPlayerWithOptions(bytes.NewReader(nil), &oto.NewPlayerOptions{
+		ChannelCount:  1,
+		ChannelMatrix: [][]float32{{1}, {0}},
+	}); err != nil {
+		t.Errorf("NewPlayerWithOptions with a valid channel matrix: %v", err)
+	}
+}
+
+func TestSoundBuffer(t *testing.T) {
+	ctx, d := newVirtualContext(t)
+
+	// The fields not specified are the context's.
+	sb, err := ctx.NewSoundBuffer(bytes.NewReader(float32Frames(100, 0.5)), &oto.NewSoundBufferOptions{
+		SampleRate: 48000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.Duration(), 100*time.Second/48000; got != want {
+		t.Errorf("Duration(): got: %v, want: %v", got, want)
+	}
+	p, err := ctx.NewPlayerFromBuffer(sb, &oto.NewPlayerOptions{Play: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := d.Advance(200)
+	for i, v := range out[:100*2] {
+		if v != 0.5 {
+			t.Fatalf("out[%d]: got: %v, want: 0.5", i, v)
+		}
+	}
+	_ = p.Close()
+
+	if _, err := ctx.NewSoundBuffer(bytes.NewReader(nil), &oto.NewSoundBufferOptions{ChannelCount: -1}); err == nil {
+		t.Errorf("NewSoundBuffer with a negative channel count must fail")
+	}
+
+	// A sound buffer cannot be played in a context with another sample rate or other channels.
+	for _, op := range []*oto.NewContextOptions{
+		{SampleRate: 44100, ChannelCount: 2},
+		{SampleRate: 48000, ChannelCount: 1},
+		{SampleRate: 48000, ChannelCount: 2, ChannelPositions: []oto.ChannelPosition{oto.ChannelPositionFrontRight, oto.ChannelPositionFrontLeft}},
+	} {
+		op.VirtualDevice = oto.NewVirtualDevice()
+		ctx, ready, err := oto.NewContext(op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-ready
+		if _, err := ctx.NewPlayerFromBuffer(sb, nil); err == nil {
+			t.Errorf("%d Hz, %d channels: NewPlayerFromBuffer must fail", op.SampleRate, op.ChannelCount)
+		}
+		_ = ctx.Close()
+	}
+}
+
+func newVirtualContext(t *testing.T) (*oto.Context, *oto.VirtualDevice) {
+	t.Helper()
+	d := oto.NewVirtualDevice()
+	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:    48000,
+		ChannelCount:  2,
+		Format:        oto.FormatFloat32LE,
+		VirtualDevice: d,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ready
+	t.Cleanup(func() {
+		_ = ctx.Close()
+	})
+	return ctx, d
+}
+
+// float32Frames returns the given number of stereo frames of v in 32-bit floats.
+func float32Frames(frames int, v float32) []byte {
+	buf := make([]byte, frames*2*4)
+	for i := 0; i < len(buf); i += 4 {
+		binary.LittleEndian.PutUint32(buf[i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func TestStreamPlayerNonBlocking(t *testing.T) {
+	ctx, d := newVirtualContext(t)
+
+	// 10ms is 480 frames.
+	p := ctx.NewStreamPlayer(&oto.NewStreamPlayerOptions{
+		QueueSize:   10 * time.Millisecond,
+		NonBlocking: true,
+	})
+	const queueSize = 480 * 2 * 4
+
+	n, err := p.Write(float32Frames(1000, 0.5))
+	if !errors.Is(err, oto.ErrStreamQueueFull) {
+		t.Errorf("Write: got: %v, want: %v", err, oto.ErrStreamQueueFull)
+	}
+	if got, want := n, queueSize; got != want {
+		t.Errorf("written bytes: got: %d, want: %d", got, want)
+	}
+	if got, want := p.QueuedSize(), queueSize; got != want {
+		t.Errorf("QueuedSize(): got: %d, want: %d", got, want)
+	}
+
+	p.Play()
+	out := d.Advance(200)
+	for i, v := range out {
+		if v != 0.5 {
+			t.Fatalf("out[%d]: got: %v, want: 0.5", i, v)
+		}
+	}
+	if got, want := p.QueuedSize(), (480-200)*2*4; got != want {
+		t.Errorf("QueuedSize() after playing: got: %d, want: %d", got, want)
+	}
+
+	// The freed space can be written again.
+	if _, err := p.Write(float32Frames(100, 0.5)); err != nil {
+		t.Errorf("Write after playing: %v", err)
+	}
+	_ = p.Close()
+}
+
+func TestStreamPlayerUnderrun(t *testing.T) {
+	ctx, d := newVirtualContext(t)
+
+	p := ctx.NewStreamPlayer(nil)
+	if _, err := p.Write(float32Frames(100, 0.5)); err != nil {
+		t.Fatal(err)
+	}
+	p.Play()
+
+	// The player plays silence after the queued data, and keeps playing.
+	out := d.Advance(300)
+	for i, v := range out[:100*2] {
+		if v != 0.5 {
+			t.Fatalf("out[%d]: got: %v, want: 0.5", i, v)
+		}
+	}
+	for i, v := range out[100*2:] {
+		if v != 0 {
+			t.Fatalf("out[%d] after the underrun: got: %v, want: 0", i+100*2, v)
+		}
+	}
+	if !p.IsPlaying() {
+		t.Errorf("IsPlaying() after the underrun: got: false, want: true")
+	}
+	if got := p.QueuedSize(); got != 0 {
+		t.Errorf("QueuedSize() after the underrun: got: %d, want: 0", got)
+	}
+
+	// The data written after the underrun is played.
+	if _, err := p.Write(float32Frames(100, 0.25)); err != nil {
+		t.Fatal(err)
+	}
+	out = d.Advance(100)
+	for i, v := range out {
+		if v != 0.25 {
+			t.Fatalf("out[%d] after writing again: got: %v, want: 0.25", i, v)
+		}
+	}
+	_ = p.Close()
+}
+
+func TestStreamPlayerBlocking(t *testing.T) {
+	ctx, d := newVirtualContext(t)
+
+	const queueFrames = 480
+	p := ctx.NewStreamPlayer(&oto.NewStreamPlayerOptions{
+		QueueSize: queueFrames * time.Second / 48000,
+	})
+	p.Play()
+
+	// Write blocks until the written data is played enough.
+	const frames = 2000
+	done := make(chan error, 1)
+	go func() {
+		n, err := p.Write(float32Frames(frames, 0.5))
+		if err == nil && n != frames*2*4 {
+			err = fmt.Errorf("written bytes: got: %d, want: %d", n, frames*2*4)
+		}
+		done <- err
+	}()
+
+	var played int
+	for written := false; !written || p.QueuedSize() > 0; {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			written = true
+			// The queue and the player's buffer of the same size can hold only a part of the data,
+			// so Write cannot finish without playing the rest.
+			if want := (frames - 2*queueFrames) * 2; played < want {
+				t.Fatalf("played samples when Write returned: got: %d, want: >= %d", played, want)
+			}
+		default:
+			// Let the writer run.
+			runtime.Gosched()
+		}
+		for _, v := range d.Advance(64) {
+			if v != 0 && v != 0.5 {
+				t.Fatalf("out: got: %v, want: 0 or 0.5", v)
+			}
+			if v == 0.5 {
+				played++
+			}
+		}
+	}
+	if got, want := played, frames*2; got != want {
+		t.Errorf("played samples: got: %d, want: %d", got, want)
+	}
+	_ = p.Close()
+}
+
+func TestStreamPlayerClose(t *testing.T) {
+	ctx, d := newVirtualContext(t)
+
+	p := ctx.NewStreamPlayer(nil)
+	if _, err := p.Write(float32Frames(100, 0.5)); err != nil {
+		t.Fatal(err)
+	}
+	p.Play()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := p.Write(float32Frames(1, 0.5)); !errors.Is(err, io.ErrClosedPipe) || n != 0 {
+		t.Errorf("Write after Close: got: (%d, %v), want: (0, %v)", n, err, io.ErrClosedPipe)
+	}
+
+	// The data queued before Close is still played, and then the player stops.
+	out := d.Advance(200)
+	for i, v := range out[:100*2] {
+		if v != 0.5 {
+			t.Fatalf("out[%d]: got: %v, want: 0.5", i, v)
+		}
+	}
+	for i, v := range out[100*2:] {
+		if v != 0 {
+			t.Fatalf("out[%d] after the end: got: %v, want: 0", i+100*2, v)
+		}
+	}
+	d.Advance(1)
+	if p.IsPlaying() {
+		t.Errorf("IsPlaying() after the end: got: true, want: false")
+	}
+}
+
+// testDriver is a Driver whose output is pulled by the test.
+type testDriver struct {
+	config    *oto.DriverConfig
+	render    func(buf []float32)
+	suspended bool
+	closed    bool
+	deviceID  string
+	err       error
+}
+
+func (d *testDriver) Open(config *oto.DriverConfig, render func(buf []float32)) error {
+	d.config = config
+	d.render = render
+	return nil
+}
+
+func (d *testDriver) Suspend() error {
+	d.suspended = true
+	return nil
+}
+
+func (d *testDriver) Resume() error {
+	d.suspended = false
+	return nil
+}
+
+func (d *testDriver) Latency() time.Duration {
+	return 20 * time.Millisecond
+}
+
+func (d *testDriver) Err() error {
+	return d.err
+}
+
+func (d *testDriver) Close() error {
+	d.closed = true
+	return nil
+}
+
+func (d *testDriver) SetOutputDevice(id string) error {
+	d.deviceID = id
+	d.config.OnDeviceEvent(oto.DeviceEvent{
+		Type:     oto.DeviceEventChanged,
+		DeviceID: id,
+	})
+	return nil
+}
+
+func TestCustomDriver(t *testing.T) {
+	d := &testDriver{}
+	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:      44100,
+		ChannelCount:    2,
+		Format:          oto.FormatFloat32LE,
+		BufferSize:      100 * time.Millisecond,
+		ApplicationName: "test",
+		Driver:          d,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ctx.Close()
+	})
+	<-ready
+
+	// OnDeviceEvent is never nil, and functions cannot be compared.
+	if d.config.OnDeviceEvent == nil {
+		t.Errorf("OnDeviceEvent: got: nil, want: non-nil")
+	}
+	config := *d.config
+	config.OnDeviceEvent = nil
+	if got, want := config, (oto.DriverConfig{
+		SampleRate:       44100,
+		ChannelCount:     2,
+		ChannelMask:      oto.ChannelMaskStereo,
+		ChannelPositions: []oto.ChannelPosition{oto.ChannelPositionFrontLeft, oto.ChannelPositionFrontRight},
+		BufferSize:       100 * time.Millisecond,
+		ApplicationName:  "test",
+	}); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("config: got: %+v, want: %+v", got, want)
+	}
+
+	p := ctx.NewPlayerFromRenderFunc(func(buf []float32) {
+		for i := range buf {
+			buf[i] = 0.25
+		}
+	})
+	p.Play()
+
+	buf := make([]float32, 512)
+	d.render(buf)
+	for i, v := range buf {
+		if v != 0.25 {
+			t.Fatalf("buf[%d]: got: %v, want: 0.25", i, v)
+		}
+	}
+
+	if err := ctx.Suspend(); err != nil {
+		t.Fatal(err)
+	}
+	if !d.suspended {
+		t.Errorf("the driver is not suspended")
+	}
+	if got, want := ctx.Latency(), 20*time.Millisecond; got != want {
+		t.Errorf("Latency(): got: %v, want: %v", got, want)
+	}
+
+	d.err = fmt.Errorf("device lost")
+	if got := ctx.Err(); got != d.err {
+		t.Errorf("Err(): got: %v, want: %v", got, d.err)
+	}
+}
+
+func TestContextClose(t *testing.T) {
+	d := oto.NewVirtualDevice()
+	op := &oto.NewContextOptions{
+		SampleRate:    48000,
+		ChannelCount:  2,
+		Format:        oto.FormatFloat32LE,
+		VirtualDevice: d,
+	}
+	ctx, ready, err := oto.NewContext(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ready
+
+	src := make([]byte, 48000*2*4)
+	for i := 0; i < len(src); i += 4 {
+		binary.LittleEndian.PutUint32(src[i:], math.Float32bits(0.5))
+	}
+	p := ctx.NewPlayer(bytes.NewReader(src))
+	p.Play()
+	d.Advance(256)
+	if !p.IsPlaying() {
+		t.Fatalf("IsPlaying() before Close: got: false, want: true")
+	}
+
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if p.IsPlaying() {
+		t.Errorf("IsPlaying() after Close: got: true, want: false")
+	}
+	if ctx.Err() == nil {
+		t.Errorf("Err() after Close: got: nil, want: an error")
+	}
+	if err := ctx.Close(); err != nil {
+		t.Errorf("second Close(): got: %v, want: nil", err)
+	}
+
+	// The device is released, and a new context can use it.
+	ctx2, ready, err := oto.NewContext(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ready
+	p2 := ctx2.NewPlayer(bytes.NewReader(src))
+	p2.Play()
+	out := d.Advance(256)
+	if out[0] != 0.5 {
+		t.Errorf("out[0] of a new context: got: %v, want: 0.5", out[0])
+	}
+	if err := ctx2.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close closes the custom driver.
+	dr := &testDriver{}
+	ctx3, _, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:   48000,
+		ChannelCount: 2,
+		Format:       oto.FormatFloat32LE,
+		Driver:       dr,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx3.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !dr.closed {
+		t.Errorf("the driver is not closed")
+	}
+}
+
+func TestSetOutputDevice(t *testing.T) {
+	var events []oto.DeviceEvent
+	d := &testDriver{}
+	ctx, _, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:   48000,
+		ChannelCount: 2,
+		Format:       oto.FormatFloat32LE,
+		Driver:       d,
+		OnDeviceEvent: func(event oto.DeviceEvent) {
+			events = append(events, event)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.SetOutputDevice("headphones"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.deviceID, "headphones"; got != want {
+		t.Errorf("device ID: got: %q, want: %q", got, want)
+	}
+	if got, want := events, []oto.DeviceEvent{{Type: oto.DeviceEventChanged, DeviceID: "headphones"}}; !slices.Equal(got, want) {
+		t.Errorf("events: got: %v, want: %v", got, want)
+	}
+
+	// A driver without SetOutputDevice doesn't support changing the device.
+	if err := theContext.SetOutputDevice("headphones"); err == nil {
+		t.Errorf("SetOutputDevice on a virtual device must fail")
+	}
+}
+
+func TestListOutputDevices(t *testing.T) {
+	devices, err := oto.ListOutputDevices()
+	if err != nil {
+		t.Skipf("ListOutputDevices is not available: %v", err)
+	}
+	var defaults int
+	for _, d := range devices {
+		if d.ID == "" {
+			t.Errorf("device %q has an empty ID", d.Name)
+		}
+		if d.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		t.Errorf("the number of the default devices: got: %d, want: 0 or 1", defaults)
+	}
 }
@@ -0,0 +1,112 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"errors"
+)
+
+// OutputDevice is an audio output device.
+type OutputDevice struct {
+	// ID is the unique identifier of the device. ID is not necessarily human-readable.
+	// Specify ID as NewContextOptions.DeviceID to output to the device.
+	ID string
+
+	// Name is the human-readable name of the device.
+	Name string
+
+	// Description is the additional human-readable information of the device, e.g. the active port like "Headphones".
+	// Description can be empty.
+	Description string
+
+	// Default reports whether the device is the default output device of the system.
+	Default bool
+}
+
+// ListOutputDevices returns the available audio output devices.
+//
+// ListOutputDevices is supported only on Linux (PulseAudio) so far.
+// On the other platforms, ListOutputDevices returns an error.
+// On Linux, ListOutputDevices connects to the default server, which can be specified by the PULSE_SERVER environment variable.
+func ListOutputDevices() ([]OutputDevice, error) {
+	return ListOutputDevicesWithOptions(nil)
+}
+
+// ListOutputDevicesOptions represents options for ListOutputDevicesWithOptions.
+type ListOutputDevicesOptions struct {
+	// ServerAddress specifies the address of the audio server. See NewContextOptions.ServerAddress.
+	ServerAddress string
+}
+
+// ListOutputDevicesWithOptions returns the available audio output devices of the audio server specified by options.
+//
+// ListOutputDevicesWithOptions works like ListOutputDevices.
+// options can be nil. In this case, ListOutputDevicesWithOptions works exactly like ListOutputDevices.
+//
+// Specify the same ServerAddress as NewContextOptions to list the devices for the context.
+func ListOutputDevicesWithOptions(options *ListOutputDevicesOptions) ([]OutputDevice, error) {
+	if options == nil {
+		options = &ListOutputDevicesOptions{}
+	}
+	return listOutputDevices(options.ServerAddress)
+}
+
+// SetOutputDevice moves the output to the device with the ID. See ListOutputDevices for the available devices.
+// If id is empty, the output moves to the default output device.
+//
+// The players keep playing at their positions.
+// When the output moves, NewContextOptions.OnDeviceEvent is called with DeviceEventChanged.
+//
+// SetOutputDevice is supported only on Linux (PulseAudio) so far.
+// On the other platforms, SetOutputDevice returns an error.
+//
+// SetOutputDevice is concurrent-safe.
+func (c *Context) SetOutputDevice(id string) error {
+	if c.closed.Load() {
+		return errContextClosed
+	}
+	d, ok := c.driver.(interface{ SetOutputDevice(id string) error })
+	if !ok {
+		return errors.New("oto: changing the output device is not supported on this driver")
+	}
+	return d.SetOutputDevice(id)
+}
+
+// DeviceEventType is the type of a DeviceEvent.
+type DeviceEventType int
+
+const (
+	// DeviceEventChanged indicates that the output moved to another device,
+	// e.g. by Context.SetOutputDevice or by following the default output device.
+	DeviceEventChanged DeviceEventType = iota
+
+	// DeviceEventDisconnected indicates that the connection to the audio server is lost, e.g. when the server restarts.
+	// The context connects to the server again automatically, and the players keep their states.
+	DeviceEventDisconnected
+
+	// DeviceEventReconnected indicates that the context connected to the audio server again,
+	// and the output is resumed.
+	DeviceEventReconnected
+)
+
+// DeviceEvent is an event of the output device of a context.
+type DeviceEvent struct {
+	// Type is the type of the event.
+	Type DeviceEventType
+
+	// DeviceID is the ID of the output device after the event.
+	// DeviceID is empty for DeviceEventDisconnected.
+	DeviceID string
+}
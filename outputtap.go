@@ -0,0 +1,65 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"io"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// OutputTap captures the final output of a context, e.g. for recording what the user hears.
+//
+// All the methods of OutputTap are concurrent-safe.
+type OutputTap struct {
+	tap *mux.OutputTap
+}
+
+// AddOutputTap starts writing a copy of the final output of the context to w in the format.
+//
+// The samples have the context's sample rate, and OutputTap.ChannelCount channels,
+// which can be fewer than the context's channel count when the device has fewer channels.
+//
+// The output is written on its own goroutine so that a slow writer never makes the audio stutter.
+// If the writer is too slow, blocks of the output are dropped and counted by DroppedBlocks.
+// Call Close to stop the capture.
+//
+// AddOutputTap is concurrent-safe.
+func (c *Context) AddOutputTap(w io.Writer, format Format) *OutputTap {
+	return &OutputTap{
+		tap: c.mux.AddOutputTap(w, mux.Format(format)),
+	}
+}
+
+// ChannelCount returns the number of the channels of the written samples.
+func (t *OutputTap) ChannelCount() int {
+	return t.tap.ChannelCount()
+}
+
+// DroppedBlocks returns the number of the output blocks that were dropped because the writer was too slow.
+func (t *OutputTap) DroppedBlocks() int64 {
+	return t.tap.DroppedBlocks()
+}
+
+// Err returns the error from the writer. After an error, nothing is written any more.
+func (t *OutputTap) Err() error {
+	return t.tap.Err()
+}
+
+// Close stops the capture, and waits for the captured output to be written.
+// Close returns the error from the writer if any.
+func (t *OutputTap) Close() error {
+	return t.tap.Close()
+}
@@ -16,6 +16,7 @@ package oto
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/ebitengine/oto/v3/internal/mux"
 )
@@ -47,17 +48,39 @@ func (p *Player) Reset() {
 	p.player.Reset()
 }
 
-// Volume returns the current volume in the range of [0, 1].
+// MaxVolume is the maximum volume of players and contexts. MaxVolume is about +12 dB.
+//
+// A volume more than 1 amplifies the sound, which is useful for quiet sources.
+// Note that an amplified sound might be clipped.
+const MaxVolume = mux.MaxVolume
+
+// Volume returns the current volume in the range of [0, MaxVolume].
 // The default volume is 1.
 func (p *Player) Volume() float64 {
 	return p.player.Volume()
 }
 
-// SetVolume sets the current volume in the range of [0, 1].
+// SetVolume sets the current volume. The volume is clamped to the range of [0, MaxVolume].
+//
+// A player with volume 0 keeps consuming its source at the normal pace, but its samples are not mixed.
+// When the volume is raised again, the player resumes at the position where it would have been.
 func (p *Player) SetVolume(volume float64) {
 	p.player.SetVolume(volume)
 }
 
+// VolumeDB returns the current volume in decibels.
+// If the volume is 0, VolumeDB returns negative infinity.
+func (p *Player) VolumeDB() float64 {
+	return volumeToDB(p.Volume())
+}
+
+// SetVolumeDB sets the current volume in decibels.
+// 0 dB is the volume 1, and negative infinity is the volume 0.
+// The volume is clamped to the range of [0, MaxVolume].
+func (p *Player) SetVolumeDB(db float64) {
+	p.SetVolume(dbToVolume(db))
+}
+
 // BufferedSize returns the byte size of the buffer data that is not sent to the audio hardware yet.
 func (p *Player) BufferedSize() int {
 	return p.player.BufferedSize()
@@ -93,3 +116,19 @@ func (p *Player) Close() error {
 	// (*mux.Player).Close() is called by the finalizer. Let's rely on it.
 	return nil
 }
+
+// volumeToDB converts a linear volume to decibels.
+func volumeToDB(volume float64) float64 {
+	if volume <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(volume)
+}
+
+// dbToVolume converts decibels to a linear volume.
+func dbToVolume(db float64) float64 {
+	if math.IsInf(db, -1) {
+		return 0
+	}
+	return math.Pow(10, db/20)
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// NewSoundBufferOptions represents options for NewSoundBuffer.
+type NewSoundBufferOptions struct {
+	// SampleRate specifies the sample rate of the source.
+	// If 0 is specified, the context's sample rate is used.
+	SampleRate int
+
+	// ChannelCount specifies the number of channels of the source.
+	// If 0 is specified, the context's channel count is used.
+	ChannelCount int
+
+	// Format specifies the format of the source.
+	// If Format is nil, the context's format is used.
+	Format *Format
+
+	// ChannelMask specifies the speaker positions of the source's channels.
+	// If ChannelMask is 0, the default positions for the channel count are used.
+	ChannelMask ChannelMask
+}
+
+// SoundBuffer is decoded PCM data in memory, shared by multiple players.
+//
+// A SoundBuffer is useful for short sounds played many times at once, like sound effects.
+// The data is decoded and converted to the context's format only once, and is never copied by players.
+//
+// A SoundBuffer is immutable. All the functions of a SoundBuffer are concurrent-safe.
+type SoundBuffer struct {
+	buffer *mux.SoundBuffer
+
+	sampleRate   int
+	channelCount int
+	channelMask  ChannelMask
+}
+
+// NewSoundBuffer reads the whole r and creates a SoundBuffer.
+//
+// The format of r is the same as the source of NewPlayer. If r implements SampleSource, float32 values are read by ReadSamples.
+//
+// options can be nil. In this case, r is assumed to be in the context's format.
+//
+// The created SoundBuffer can be played only by the context's players, or the players of another context
+// with the same sample rate and the same channels.
+//
+// NewSoundBuffer is concurrent-safe.
+func (c *Context) NewSoundBuffer(r io.Reader, options *NewSoundBufferOptions) (*SoundBuffer, error) {
+	var op *mux.PlayerOptions
+	if options != nil {
+		var err error
+		op, err = c.sourceOptions(options.SampleRate, options.ChannelCount, options.Format, options.ChannelMask)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b, err := c.mux.NewSoundBuffer(r, op)
+	if err != nil {
+		return nil, err
+	}
+	return &SoundBuffer{
+		buffer:       b,
+		sampleRate:   c.sampleRate,
+		channelCount: c.channelCount,
+		channelMask:  c.channelMask,
+	}, nil
+}
+
+// Duration returns the duration of the sound.
+func (b *SoundBuffer) Duration() time.Duration {
+	frames := int64(b.buffer.Len() / b.channelCount)
+	return time.Duration(frames * int64(time.Second) / int64(b.sampleRate))
+}
+
+// NewPlayerFromBuffer creates a new, ready-to-use Player playing b.
+//
+// Unlike a reader, b can be shared by any number of players. Each player has only its own position.
+// A player created from a SoundBuffer has no underlying buffer, so Reset and SetBufferSize have no effects on it,
+// and its BufferedSize always returns 0.
+// Seek is always available, and its offset is in bytes of the context's format.
+//
+// options can be nil. In this case, the default options are used.
+// Only Volume, Loop, Bus, and Play of options are used.
+//
+// NewPlayerFromBuffer returns an error if b was created for a context with a different sample rate or different channels,
+// or if the bus of options belongs to another context.
+//
+// NewPlayerFromBuffer is concurrent-safe.
+//
+// All the functions of a Player returned by NewPlayerFromBuffer are concurrent-safe.
+func (c *Context) NewPlayerFromBuffer(b *SoundBuffer, options *NewPlayerOptions) (*Player, error) {
+	if b.sampleRate != c.sampleRate {
+		return nil, fmt.Errorf("oto: the sound buffer's sample rate doesn't match the context's: %d vs %d", b.sampleRate, c.sampleRate)
+	}
+	if b.channelCount != c.channelCount || b.channelMask != c.channelMask {
+		return nil, errors.New("oto: the sound buffer's channels don't match the context's")
+	}
+
+	op := &mux.PlayerOptions{
+		Volume: 1,
+	}
+	if options != nil {
+		op.Loop = options.Loop
+		if options.Volume != nil {
+			op.Volume = *options.Volume
+		}
+		var err error
+		op.Bus, err = c.muxBus(options.Bus)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p := &Player{
+		player: c.mux.NewPlayerFromSoundBuffer(b.buffer, op),
+	}
+	if options != nil && options.Play {
+		p.Play()
+	}
+	return p, nil
+}
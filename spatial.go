@@ -0,0 +1,181 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// Vector is a 3D vector used for positional audio.
+//
+// The coordinate system is right-handed. By default, the listener faces -Z, and +Y is up.
+type Vector struct {
+	X, Y, Z float64
+}
+
+// Listener represents the listener of positional audio, e.g. the camera or the player character.
+type Listener struct {
+	// Position is the position of the listener.
+	Position Vector
+
+	// Velocity is the velocity of the listener in the units of positions per second.
+	// Velocity is used only for the Doppler effect.
+	Velocity Vector
+
+	// Forward and Up are the orientation of the listener.
+	// If they are zero or parallel, (0, 0, -1) and (0, 1, 0) are used.
+	Forward Vector
+	Up      Vector
+
+	// SpeedOfSound is the speed of sound in the units of positions per second.
+	// If SpeedOfSound is 0, 343.3 is used, which assumes the unit is a meter.
+	SpeedOfSound float64
+}
+
+// Attenuation is a distance attenuation model.
+type Attenuation int
+
+const (
+	// AttenuationInverse attenuates a sound by the inverse distance:
+	// RefDistance / (RefDistance + Rolloff * (distance - RefDistance)).
+	AttenuationInverse Attenuation = Attenuation(mux.AttenuationInverse)
+
+	// AttenuationLinear attenuates a sound linearly from RefDistance to MaxDistance:
+	// 1 - Rolloff * (distance - RefDistance) / (MaxDistance - RefDistance).
+	AttenuationLinear Attenuation = Attenuation(mux.AttenuationLinear)
+
+	// AttenuationNone doesn't attenuate a sound.
+	AttenuationNone Attenuation = Attenuation(mux.AttenuationNone)
+)
+
+// Emitter represents the spatial properties of a player.
+type Emitter struct {
+	// Position is the position of the sound.
+	Position Vector
+
+	// Velocity is the velocity of the sound in the units of positions per second.
+	// Velocity is used only for the Doppler effect.
+	Velocity Vector
+
+	// Attenuation is the distance attenuation model.
+	Attenuation Attenuation
+
+	// AttenuationFunc is a custom distance attenuation, which returns the gain for the distance.
+	// If AttenuationFunc is not nil, Attenuation is ignored.
+	//
+	// AttenuationFunc is called on the audio thread for each buffer. AttenuationFunc must not block.
+	AttenuationFunc func(distance float64) float64
+
+	// RefDistance is the distance where the gain is 1. The sound is not attenuated within RefDistance.
+	// If RefDistance is 0, 1 is used.
+	RefDistance float64
+
+	// MaxDistance is the distance where the attenuation stops.
+	// If MaxDistance is 0, there is no limit for AttenuationInverse, and 100 is used for AttenuationLinear.
+	MaxDistance float64
+
+	// Rolloff is the rolloff factor of the attenuation. If Rolloff is 0, 1 is used.
+	Rolloff float64
+
+	// AirAbsorption is the amount of high frequency absorption by air per distance.
+	// The sound is low-pass filtered with the cutoff frequency 20000 / (1 + AirAbsorption * distance) [Hz].
+	// If AirAbsorption is 0, no absorption is applied.
+	AirAbsorption float64
+
+	// DopplerFactor scales the Doppler effect. 1 is the physical effect.
+	// If DopplerFactor is 0, no Doppler effect is applied.
+	DopplerFactor float64
+}
+
+func (v Vector) toMux() mux.Vector {
+	return mux.Vector{X: v.X, Y: v.Y, Z: v.Z}
+}
+
+func vectorFromMux(v mux.Vector) Vector {
+	return Vector{X: v.X, Y: v.Y, Z: v.Z}
+}
+
+// Listener returns the current listener of the context.
+//
+// Listener is concurrent-safe.
+func (c *Context) Listener() Listener {
+	l := c.mux.Listener()
+	return Listener{
+		Position:     vectorFromMux(l.Position),
+		Velocity:     vectorFromMux(l.Velocity),
+		Forward:      vectorFromMux(l.Forward),
+		Up:           vectorFromMux(l.Up),
+		SpeedOfSound: l.SpeedOfSound,
+	}
+}
+
+// SetListener sets the listener of the context.
+// The listener is used for all the players with emitters.
+//
+// SetListener is concurrent-safe.
+func (c *Context) SetListener(listener Listener) {
+	c.mux.SetListener(mux.Listener{
+		Position:     listener.Position.toMux(),
+		Velocity:     listener.Velocity.toMux(),
+		Forward:      listener.Forward.toMux(),
+		Up:           listener.Up.toMux(),
+		SpeedOfSound: listener.SpeedOfSound,
+	})
+}
+
+// Emitter returns a copy of the player's emitter, or nil if the player has no emitter.
+func (p *Player) Emitter() *Emitter {
+	e := p.player.Emitter()
+	if e == nil {
+		return nil
+	}
+	return &Emitter{
+		Position:        vectorFromMux(e.Position),
+		Velocity:        vectorFromMux(e.Velocity),
+		Attenuation:     Attenuation(e.Attenuation),
+		AttenuationFunc: e.AttenuationFunc,
+		RefDistance:     e.RefDistance,
+		MaxDistance:     e.MaxDistance,
+		Rolloff:         e.Rolloff,
+		AirAbsorption:   e.AirAbsorption,
+		DopplerFactor:   e.DopplerFactor,
+	}
+}
+
+// SetEmitter makes the player positional with the emitter.
+//
+// A positional player's channels are mixed into mono, and the sound is panned to the context's channels
+// by the direction from the listener. The distance attenuation, the air absorption and the Doppler effect
+// are applied as well. The parameters are updated for each buffer of the audio device,
+// so call SetEmitter every frame to move the sound.
+//
+// The emitter is copied. If emitter is nil, the player is not positional anymore.
+func (p *Player) SetEmitter(emitter *Emitter) {
+	if emitter == nil {
+		p.player.SetEmitter(nil)
+		return
+	}
+	p.player.SetEmitter(&mux.Emitter{
+		Position:        emitter.Position.toMux(),
+		Velocity:        emitter.Velocity.toMux(),
+		Attenuation:     mux.Attenuation(emitter.Attenuation),
+		AttenuationFunc: emitter.AttenuationFunc,
+		RefDistance:     emitter.RefDistance,
+		MaxDistance:     emitter.MaxDistance,
+		Rolloff:         emitter.Rolloff,
+		AirAbsorption:   emitter.AirAbsorption,
+		DopplerFactor:   emitter.DopplerFactor,
+	})
+}
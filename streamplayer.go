@@ -0,0 +1,186 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrStreamQueueFull is returned by StreamPlayer's Write when the player is non-blocking and its queue is full.
+var ErrStreamQueueFull = errors.New("oto: stream queue is full")
+
+// NewStreamPlayerOptions represents options for NewStreamPlayer.
+type NewStreamPlayerOptions struct {
+	// QueueSize specifies the maximum duration of data queued by Write.
+	//
+	// If 0 or a negative value is specified, 100ms is used.
+	QueueSize time.Duration
+
+	// NonBlocking specifies whether Write fails with ErrStreamQueueFull instead of blocking when the queue is full.
+	NonBlocking bool
+}
+
+// StreamPlayer is a player whose PCM data is pushed by Write instead of pulled from an io.Reader.
+//
+// The format of written data is the same as the source of NewPlayer.
+// When the queue runs out of data, the player plays silence until more data is written.
+//
+// All the functions of a StreamPlayer are concurrent-safe.
+type StreamPlayer struct {
+	*Player
+
+	queue *streamQueue
+}
+
+// NewStreamPlayer creates a new, ready-to-use StreamPlayer belonging to the Context.
+//
+// options can be nil. In this case, the default options are used.
+func (c *Context) NewStreamPlayer(options *NewStreamPlayerOptions) *StreamPlayer {
+	if options == nil {
+		options = &NewStreamPlayerOptions{}
+	}
+	queueSize := options.QueueSize
+	if queueSize <= 0 {
+		queueSize = 100 * time.Millisecond
+	}
+	size := max(c.durationToBytes(queueSize), 1)
+
+	q := &streamQueue{
+		buf:         make([]byte, size),
+		nonBlocking: options.NonBlocking,
+	}
+	q.cond = sync.NewCond(&q.m)
+
+	// Keep the player's own buffer as small as the queue not to add latency.
+	p, err := c.NewPlayerWithOptions(q, &NewPlayerOptions{
+		BufferSize: queueSize,
+	})
+	if err != nil {
+		// The options are always valid as the other values are the context's.
+		panic(err)
+	}
+	return &StreamPlayer{
+		Player: p,
+		queue:  q,
+	}
+}
+
+// Write implements io.Writer.
+//
+// Write blocks until all the data is queued, unless the player is non-blocking.
+// A non-blocking player queues as much data as possible and returns ErrStreamQueueFull if some data is left.
+//
+// Write returns io.ErrClosedPipe after Close is called.
+func (s *StreamPlayer) Write(buf []byte) (int, error) {
+	return s.queue.write(buf)
+}
+
+// QueuedSize returns the byte size of the data that is written but not played yet.
+// This includes the data in the player's underlying buffer.
+func (s *StreamPlayer) QueuedSize() int {
+	return s.queue.len() + s.BufferedSize()
+}
+
+// Close implements io.Closer.
+//
+// Close stops accepting writes. The data already queued is still played, and then the player stops.
+func (s *StreamPlayer) Close() error {
+	s.queue.close()
+	return nil
+}
+
+// streamQueue is a ring buffer that connects Write calls to the player's reads.
+type streamQueue struct {
+	buf    []byte
+	head   int
+	length int
+	closed bool
+
+	nonBlocking bool
+
+	m    sync.Mutex
+	cond *sync.Cond
+}
+
+func (q *streamQueue) write(buf []byte) (int, error) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	var written int
+	for len(buf) > 0 {
+		if q.closed {
+			return written, io.ErrClosedPipe
+		}
+		if q.length == len(q.buf) {
+			if q.nonBlocking {
+				return written, ErrStreamQueueFull
+			}
+			q.cond.Wait()
+			continue
+		}
+
+		tail := (q.head + q.length) % len(q.buf)
+		end := len(q.buf)
+		if tail < q.head {
+			end = q.head
+		}
+		n := copy(q.buf[tail:end], buf)
+		q.length += n
+		written += n
+		buf = buf[n:]
+	}
+	return written, nil
+}
+
+// Read is called by the player. Read never blocks so that an underrun results in silence.
+func (q *streamQueue) Read(buf []byte) (int, error) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if q.length == 0 {
+		if q.closed {
+			return 0, io.EOF
+		}
+		return 0, nil
+	}
+
+	var read int
+	for len(buf) > 0 && q.length > 0 {
+		end := min(q.head+q.length, len(q.buf))
+		n := copy(buf, q.buf[q.head:end])
+		q.head = (q.head + n) % len(q.buf)
+		q.length -= n
+		read += n
+		buf = buf[n:]
+	}
+	q.cond.Broadcast()
+	return read, nil
+}
+
+func (q *streamQueue) len() int {
+	q.m.Lock()
+	defer q.m.Unlock()
+	return q.length
+}
+
+func (q *streamQueue) close() {
+	q.m.Lock()
+	defer q.m.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
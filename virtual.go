@@ -0,0 +1,123 @@
+// Copyright 2026 The Oto Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oto
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ebitengine/oto/v3/internal/mux"
+)
+
+// VirtualDevice is an audio device without hardware whose clock is advanced explicitly, e.g. for tests.
+//
+// Specify a VirtualDevice as NewContextOptions.VirtualDevice to create a context outputting to it.
+// Nothing is played until Advance is called, and then the output is rendered in the same way as an OfflineContext.
+//
+// All the methods of VirtualDevice are concurrent-safe.
+type VirtualDevice struct {
+	mux          *mux.Mux
+	channelCount int
+
+	position  int64
+	suspended bool
+	m         sync.Mutex
+}
+
+// NewVirtualDevice creates a new VirtualDevice.
+func NewVirtualDevice() *VirtualDevice {
+	return &VirtualDevice{}
+}
+
+// attach makes the device the output of the mux.
+func (d *VirtualDevice) attach(m *mux.Mux, channelCount int) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.mux != nil {
+		return fmt.Errorf("oto: the virtual device is already used by another context")
+	}
+	d.mux = m
+	d.channelCount = channelCount
+	return nil
+}
+
+// Advance advances the clock of the device by the given number of frames, and returns the output of the frames.
+//
+// The players' sources are read synchronously before rendering.
+// Then, the output is deterministic unless a source doesn't provide data immediately.
+//
+// If the context is suspended, Advance returns silence and the players don't advance.
+// Advance panics if the device is not used by a context.
+func (d *VirtualDevice) Advance(frames int) []float32 {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.mux == nil {
+		panic("oto: the virtual device is not used by a context")
+	}
+
+	buf := make([]float32, frames*d.channelCount)
+	if !d.suspended {
+		renderBlocks(d.mux, buf, d.channelCount)
+	}
+	d.position += int64(frames)
+	return buf
+}
+
+// Position returns the total number of the frames the clock has advanced.
+func (d *VirtualDevice) Position() int64 {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.position
+}
+
+// virtualDriver is a driver of a context outputting to a VirtualDevice.
+type virtualDriver struct {
+	device *VirtualDevice
+}
+
+func (v virtualDriver) Suspend() error {
+	v.device.setSuspended(true)
+	return nil
+}
+
+func (v virtualDriver) Resume() error {
+	v.device.setSuspended(false)
+	return nil
+}
+
+func (v virtualDriver) Err() error {
+	return nil
+}
+
+func (v virtualDriver) Close() error {
+	v.device.detach()
+	return nil
+}
+
+// detach releases the device from the context so that the device can be used by a new context.
+func (d *VirtualDevice) detach() {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.mux = nil
+	d.suspended = false
+}
+
+func (d *VirtualDevice) setSuspended(suspended bool) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.suspended = suspended
+}